@@ -6,16 +6,33 @@ import (
 	"errors"
 )
 
-// BecomeUser switches to the given userName if not empty.
+type userIdentity struct{}
+
+func lookupIdentity(string, []string) (userIdentity, error) {
+	return userIdentity{}, errors.ErrUnsupported
+}
+
+func (userIdentity) setIDs() error {
+	return errors.ErrUnsupported
+}
+
+func (userIdentity) setEnv() (func(), error) {
+	return func() {}, errors.ErrUnsupported
+}
+
+// BecomeUser switches to the user given by userSpec if not empty.
 //
-// It sets the GID, UID and changes the USER and HOME
-// environment variables accordingly. It unsets XDG_CONFIG_HOME.
+// userSpec is a user name or numeric user id, optionally followed by a colon
+// and a group name or id. If supplementaryGroups are given they replace the
+// supplementary groups of the process. It sets the GID, UID and changes the
+// USER and HOME environment variables accordingly. It unsets the XDG base
+// directory variables.
 //
 // Returns an error matching both [ErrBecomeUser] and [errors.ErrUnsupported]
 // if the current OS is not supported.
-func BecomeUser(userName string) (err error) {
-	if userName != "" {
-		err = newErrBecomeUser(userName, errors.ErrUnsupported)
+func BecomeUser(userSpec string, supplementaryGroups ...string) (err error) {
+	if userSpec != "" {
+		err = newErrBecomeUser(userSpec, errors.ErrUnsupported)
 	}
 	return
 }
@@ -3,23 +3,38 @@
 package webserv
 
 import (
+	"errors"
 	"os"
 	"os/user"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 )
 
 var (
-	lookupUserFn = user.Lookup
-	groupIDsFn   = func(u *user.User) ([]string, error) { return u.GroupIds() }
-	geteuidFn    = os.Geteuid
-	setgroupsFn  = syscall.Setgroups
-	setgidFn     = syscall.Setgid
-	setuidFn     = syscall.Setuid
-	unsetenvFn   = os.Unsetenv
-	setenvFn     = os.Setenv
+	lookupUserFn    = user.Lookup
+	lookupUserIdFn  = user.LookupId
+	lookupGroupFn   = user.LookupGroup
+	groupIDsFn      = func(u *user.User) ([]string, error) { return u.GroupIds() }
+	geteuidFn       = os.Geteuid
+	setgroupsFn     = syscall.Setgroups
+	setgidFn        = syscall.Setgid
+	setuidFn        = syscall.Setuid
+	unsetenvFn      = os.Unsetenv
+	setenvFn        = os.Setenv
+	errNoPasswdUser = errors.New("no passwd entry for numeric user id; specify the group as uid:gid")
 )
 
+// userIdentity is the resolved target of a user switch.
+type userIdentity struct {
+	name   string // value for the USER environment variable
+	home   string // value for the HOME environment variable
+	uid    int
+	gid    int
+	groups []int // supplementary groups, or nil to leave them unchanged
+}
+
 func parseGroupIDs(groupIDs []string) (gids []int, err error) {
 	gids = make([]int, 0, len(groupIDs))
 	for i := 0; i < len(groupIDs) && err == nil; i++ {
@@ -31,50 +46,169 @@ func parseGroupIDs(groupIDs []string) (gids []int, err error) {
 	return
 }
 
-// BecomeUser switches to the given userName if not empty.
+// lookupGroupID returns the numeric id of group, which may be a group name or
+// a numeric id. Numeric ids are used as-is without consulting the group database.
+func lookupGroupID(group string) (gid int, err error) {
+	if gid, err = strconv.Atoi(group); err != nil {
+		var g *user.Group
+		if g, err = lookupGroupFn(group); err == nil {
+			gid, err = strconv.Atoi(g.Gid)
+		}
+	}
+	return
+}
+
+// lookupUser resolves the user part of a user specification, which may be a
+// user name or a numeric user id. A numeric id without a passwd entry yields
+// a nil *user.User and no error.
+func lookupUser(name string) (u *user.User, uid int, err error) {
+	if uid, err = strconv.Atoi(name); err != nil {
+		if u, err = lookupUserFn(name); err == nil {
+			uid, err = strconv.Atoi(u.Uid)
+		}
+	} else if u, err = lookupUserIdFn(name); err != nil {
+		if errors.As(err, new(user.UnknownUserIdError)) {
+			err = nil
+		}
+	}
+	return
+}
+
+// lookupIdentity resolves userSpec ("user", "user:group", "uid" or "uid:gid")
+// and the optional explicit supplementary groups into a userIdentity.
+func lookupIdentity(userSpec string, groups []string) (id userIdentity, err error) {
+	userName, groupName, _ := strings.Cut(userSpec, ":")
+	var u *user.User
+	if u, id.uid, err = lookupUser(userName); err == nil {
+		id.name, id.home = userName, "/"
+		if u != nil {
+			id.name, id.home = u.Username, u.HomeDir
+		}
+		if groupName != "" {
+			id.gid, err = lookupGroupID(groupName)
+		} else if u != nil {
+			id.gid, err = strconv.Atoi(u.Gid)
+		} else {
+			err = errNoPasswdUser
+		}
+		if err == nil {
+			if len(groups) > 0 {
+				id.groups = make([]int, 0, len(groups))
+				for i := 0; i < len(groups) && err == nil; i++ {
+					var gid int
+					if gid, err = lookupGroupID(groups[i]); err == nil {
+						id.groups = append(id.groups, gid)
+					}
+				}
+			} else if geteuidFn() == 0 {
+				var groupIDs []string
+				if u != nil {
+					groupIDs, err = groupIDsFn(u)
+				}
+				if err == nil {
+					if id.groups, err = parseGroupIDs(groupIDs); err == nil {
+						if len(id.groups) == 0 {
+							id.groups = []int{id.gid}
+						}
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// setIDs sets the supplementary groups (if any), the GID and the UID, in that
+// order.
+func (id userIdentity) setIDs() (err error) {
+	if id.groups != nil {
+		err = setgroupsFn(id.groups)
+	}
+	if err == nil {
+		if err = setgidFn(id.gid); err == nil {
+			err = setuidFn(id.uid)
+		}
+	}
+	return
+}
+
+// userRuntimeDirBase holds the per-user runtime directories created by
+// systemd-logind, named by uid.
+var userRuntimeDirBase = "/run/user"
+
+// userEnvKeys are the environment variables setEnv changes.
+var userEnvKeys = []string{"HOME", "USER", "XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME", "XDG_CACHE_HOME", "XDG_RUNTIME_DIR"}
+
+// setEnv sets HOME and USER to match the user and unsets the XDG base
+// directory variables so directory lookups follow the new HOME.
+// XDG_RUNTIME_DIR is set to the user's directory under /run/user if it exists.
+// Calling restore puts the variables back as they were, even if err is not
+// nil.
+func (id userIdentity) setEnv() (restore func(), err error) {
+	saved := make(map[string]*string, len(userEnvKeys))
+	for _, key := range userEnvKeys {
+		if value, ok := os.LookupEnv(key); ok {
+			saved[key] = &value
+		} else {
+			saved[key] = nil
+		}
+	}
+	restore = func() {
+		for key, value := range saved {
+			if value != nil {
+				_ = setenvFn(key, *value)
+			} else {
+				_ = unsetenvFn(key)
+			}
+		}
+	}
+	for _, key := range userEnvKeys[2:] {
+		_ = unsetenvFn(key)
+	}
+	if err = setenvFn("HOME", id.home); err == nil {
+		if err = setenvFn("USER", id.name); err == nil {
+			runtimeDir := filepath.Join(userRuntimeDirBase, strconv.Itoa(id.uid))
+			if fi, statErr := os.Stat(runtimeDir); statErr == nil && fi.IsDir() {
+				err = setenvFn("XDG_RUNTIME_DIR", runtimeDir)
+			}
+		}
+	}
+	return
+}
+
+// BecomeUser switches to the user given by userSpec if not empty.
 //
-// When running as root (euid 0) it resets the supplementary groups to those of
-// the target user and then sets the GID and UID, in that order (setgroups,
-// setgid, setuid). When not root the supplementary-groups reset is skipped and
-// setgid/setuid will fail unless the target ids already match the process.
+// userSpec is a user name or numeric user id, optionally followed by a colon
+// and a group name or numeric group id to use instead of the user's primary
+// group: "www-data", "www-data:ssl-cert", "1000" or "1000:1000". A numeric
+// user id need not exist in the passwd database (as in minimal containers),
+// but then the group must be given explicitly, HOME is set to "/" and USER to
+// the numeric id.
 //
-// It then sets the HOME and USER environment variables to match the target user
-// and unsets XDG_CONFIG_HOME so config-directory lookups follow the new HOME.
+// If supplementaryGroups are given (as names or numeric ids), they replace the
+// supplementary groups of the process. Otherwise, when running as root (euid
+// 0), the supplementary groups are reset to those of the target user, or to
+// just the primary group if there are none. When not root and no groups are
+// given, the supplementary-groups reset is skipped and setgid/setuid will fail
+// unless the target ids already match the process (or the process holds
+// CAP_SETGID and CAP_SETUID).
+//
+// The changes are made in the order setgroups, setgid, setuid. It then sets the
+// HOME and USER environment variables to match the target user and unsets
+// XDG_CONFIG_HOME, XDG_DATA_HOME, XDG_STATE_HOME and XDG_CACHE_HOME so
+// directory lookups follow the new HOME. XDG_RUNTIME_DIR is set to
+// /run/user/<uid> if that directory exists, and unset otherwise.
 //
 // Returns an error matching [ErrBecomeUser] on failure.
-func BecomeUser(userName string) error {
+func BecomeUser(userSpec string, supplementaryGroups ...string) error {
 	var err error
-	if userName != "" {
-		var u *user.User
-		if u, err = lookupUserFn(userName); err == nil {
-			var uid, gid int
-			if uid, err = strconv.Atoi(u.Uid); err == nil {
-				if gid, err = strconv.Atoi(u.Gid); err == nil {
-					if geteuidFn() == 0 {
-						var groupIDs []string
-						if groupIDs, err = groupIDsFn(u); err == nil {
-							var gids []int
-							if gids, err = parseGroupIDs(groupIDs); err == nil {
-								if len(gids) == 0 {
-									gids = []int{gid}
-								}
-								err = setgroupsFn(gids)
-							}
-						}
-					}
-					if err == nil {
-						if err = setgidFn(gid); err == nil {
-							if err = setuidFn(uid); err == nil {
-								_ = unsetenvFn("XDG_CONFIG_HOME")
-								if err = setenvFn("HOME", u.HomeDir); err == nil {
-									err = setenvFn("USER", u.Username)
-								}
-							}
-						}
-					}
-				}
+	if userSpec != "" {
+		var id userIdentity
+		if id, err = lookupIdentity(userSpec, supplementaryGroups); err == nil {
+			if err = id.setIDs(); err == nil {
+				_, err = id.setEnv()
 			}
 		}
 	}
-	return newErrBecomeUser(userName, err)
+	return newErrBecomeUser(userSpec, err)
 }
@@ -4,37 +4,47 @@ package webserv
 
 import (
 	"errors"
+	"os"
 	"os/user"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
 
 type becomeUserFns struct {
-	lookupUserFn func(username string) (*user.User, error)
-	groupIDsFn   func(u *user.User) ([]string, error)
-	geteuidFn    func() int
-	setgroupsFn  func(gids []int) error
-	setgidFn     func(gid int) error
-	setuidFn     func(uid int) error
-	unsetenvFn   func(key string) error
-	setenvFn     func(key, value string) error
+	lookupUserFn   func(username string) (*user.User, error)
+	lookupUserIdFn func(uid string) (*user.User, error)
+	lookupGroupFn  func(name string) (*user.Group, error)
+	groupIDsFn     func(u *user.User) ([]string, error)
+	geteuidFn      func() int
+	setgroupsFn    func(gids []int) error
+	setgidFn       func(gid int) error
+	setuidFn       func(uid int) error
+	unsetenvFn     func(key string) error
+	setenvFn       func(key, value string) error
+	runtimeDirBase string
 }
 
 func captureBecomeUserFns() becomeUserFns {
 	return becomeUserFns{
-		lookupUserFn: lookupUserFn,
-		groupIDsFn:   groupIDsFn,
-		geteuidFn:    geteuidFn,
-		setgroupsFn:  setgroupsFn,
-		setgidFn:     setgidFn,
-		setuidFn:     setuidFn,
-		unsetenvFn:   unsetenvFn,
-		setenvFn:     setenvFn,
+		lookupUserFn:   lookupUserFn,
+		lookupUserIdFn: lookupUserIdFn,
+		lookupGroupFn:  lookupGroupFn,
+		groupIDsFn:     groupIDsFn,
+		geteuidFn:      geteuidFn,
+		setgroupsFn:    setgroupsFn,
+		setgidFn:       setgidFn,
+		setuidFn:       setuidFn,
+		unsetenvFn:     unsetenvFn,
+		setenvFn:       setenvFn,
+		runtimeDirBase: userRuntimeDirBase,
 	}
 }
 
 func restoreBecomeUserFns(fns becomeUserFns) {
 	lookupUserFn = fns.lookupUserFn
+	lookupUserIdFn = fns.lookupUserIdFn
+	lookupGroupFn = fns.lookupGroupFn
 	groupIDsFn = fns.groupIDsFn
 	geteuidFn = fns.geteuidFn
 	setgroupsFn = fns.setgroupsFn
@@ -42,6 +52,7 @@ func restoreBecomeUserFns(fns becomeUserFns) {
 	setuidFn = fns.setuidFn
 	unsetenvFn = fns.unsetenvFn
 	setenvFn = fns.setenvFn
+	userRuntimeDirBase = fns.runtimeDirBase
 }
 
 func TestBecomeUser_RootSetsSupplementaryGroupsBeforeDroppingPrivileges(t *testing.T) {
@@ -49,7 +60,8 @@ func TestBecomeUser_RootSetsSupplementaryGroupsBeforeDroppingPrivileges(t *testi
 	defer restoreBecomeUserFns(saved)
 
 	u := &user.User{Username: "svc", Uid: "101", Gid: "201", HomeDir: "/tmp/svc"}
-	sequence := make([]string, 0, 8)
+	sequence := make([]string, 0, 12)
+	userRuntimeDirBase = t.TempDir()
 	gotGroups := []int(nil)
 
 	lookupUserFn = func(username string) (*user.User, error) {
@@ -108,6 +120,10 @@ func TestBecomeUser_RootSetsSupplementaryGroupsBeforeDroppingPrivileges(t *testi
 		"setgid",
 		"setuid",
 		"unsetenv:XDG_CONFIG_HOME",
+		"unsetenv:XDG_DATA_HOME",
+		"unsetenv:XDG_STATE_HOME",
+		"unsetenv:XDG_CACHE_HOME",
+		"unsetenv:XDG_RUNTIME_DIR",
 		"setenv:HOME=/tmp/svc",
 		"setenv:USER=svc",
 	}
@@ -243,3 +259,225 @@ func TestBecomeUser_RootWithoutSupplementaryGroupsFallsBackToPrimaryGroup(t *tes
 		t.Fatalf("groups=%v want %v", gotGroups, wantGroups)
 	}
 }
+
+// stubSwitch records the setgroups/setgid/setuid calls and environment changes
+// made by BecomeUser without touching the process.
+type stubSwitch struct {
+	groups []int
+	gid    int
+	uid    int
+	env    map[string]string
+}
+
+func installStubSwitch(euid int) *stubSwitch {
+	sw := &stubSwitch{gid: -1, uid: -1, env: map[string]string{}}
+	geteuidFn = func() int { return euid }
+	setgroupsFn = func(gids []int) error {
+		sw.groups = append([]int{}, gids...)
+		return nil
+	}
+	setgidFn = func(gid int) error {
+		sw.gid = gid
+		return nil
+	}
+	setuidFn = func(uid int) error {
+		sw.uid = uid
+		return nil
+	}
+	unsetenvFn = func(_ string) error { return nil }
+	setenvFn = func(key, value string) error {
+		sw.env[key] = value
+		return nil
+	}
+	return sw
+}
+
+func TestBecomeUser_UserColonGroupOverridesPrimaryGroup(t *testing.T) {
+	saved := captureBecomeUserFns()
+	defer restoreBecomeUserFns(saved)
+
+	u := &user.User{Username: "nobody", Uid: "65534", Gid: "65534", HomeDir: "/nonexistent"}
+	lookupUserFn = func(_ string) (*user.User, error) { return u, nil }
+	lookupGroupFn = func(name string) (*user.Group, error) {
+		if name != "ssl-cert" {
+			t.Fatalf("lookup group %q", name)
+		}
+		return &user.Group{Name: name, Gid: "110"}, nil
+	}
+	groupIDsFn = func(_ *user.User) ([]string, error) { return nil, nil }
+	sw := installStubSwitch(0)
+
+	if err := BecomeUser("nobody:ssl-cert"); err != nil {
+		t.Fatal(err)
+	}
+	if sw.uid != 65534 || sw.gid != 110 || !reflect.DeepEqual(sw.groups, []int{110}) {
+		t.Fatalf("uid=%d gid=%d groups=%v", sw.uid, sw.gid, sw.groups)
+	}
+	if sw.env["USER"] != "nobody" || sw.env["HOME"] != "/nonexistent" {
+		t.Fatalf("env=%v", sw.env)
+	}
+}
+
+func TestBecomeUser_NumericIDsWithoutPasswdEntry(t *testing.T) {
+	saved := captureBecomeUserFns()
+	defer restoreBecomeUserFns(saved)
+
+	lookupUserFn = func(name string) (*user.User, error) {
+		t.Fatalf("name lookup for numeric id %q", name)
+		return nil, nil
+	}
+	lookupUserIdFn = func(uid string) (*user.User, error) { return nil, user.UnknownUserIdError(1234) }
+	lookupGroupFn = func(name string) (*user.Group, error) {
+		t.Fatalf("group lookup for numeric id %q", name)
+		return nil, nil
+	}
+	groupIDsFn = func(_ *user.User) ([]string, error) {
+		t.Fatal("groupIDsFn called without passwd entry")
+		return nil, nil
+	}
+	sw := installStubSwitch(0)
+
+	if err := BecomeUser("1234:5678"); err != nil {
+		t.Fatal(err)
+	}
+	if sw.uid != 1234 || sw.gid != 5678 || !reflect.DeepEqual(sw.groups, []int{5678}) {
+		t.Fatalf("uid=%d gid=%d groups=%v", sw.uid, sw.gid, sw.groups)
+	}
+	if sw.env["USER"] != "1234" || sw.env["HOME"] != "/" {
+		t.Fatalf("env=%v", sw.env)
+	}
+}
+
+func TestBecomeUser_NumericIDWithPasswdEntryUsesIt(t *testing.T) {
+	saved := captureBecomeUserFns()
+	defer restoreBecomeUserFns(saved)
+
+	u := &user.User{Username: "svc", Uid: "101", Gid: "201", HomeDir: "/tmp/svc"}
+	lookupUserIdFn = func(uid string) (*user.User, error) {
+		if uid != "101" {
+			t.Fatalf("lookup uid %q", uid)
+		}
+		return u, nil
+	}
+	sw := installStubSwitch(1000)
+
+	if err := BecomeUser("101"); err != nil {
+		t.Fatal(err)
+	}
+	if sw.uid != 101 || sw.gid != 201 || sw.groups != nil {
+		t.Fatalf("uid=%d gid=%d groups=%v", sw.uid, sw.gid, sw.groups)
+	}
+	if sw.env["USER"] != "svc" || sw.env["HOME"] != "/tmp/svc" {
+		t.Fatalf("env=%v", sw.env)
+	}
+}
+
+func TestBecomeUser_NumericIDWithoutPasswdEntryRequiresGroup(t *testing.T) {
+	saved := captureBecomeUserFns()
+	defer restoreBecomeUserFns(saved)
+
+	lookupUserIdFn = func(uid string) (*user.User, error) { return nil, user.UnknownUserIdError(1234) }
+	sw := installStubSwitch(0)
+
+	err := BecomeUser("1234")
+	if !errors.Is(err, ErrBecomeUser) || !errors.Is(err, errNoPasswdUser) {
+		t.Fatalf("BecomeUser() = %v, want match %v and %v", err, ErrBecomeUser, errNoPasswdUser)
+	}
+	if sw.uid != -1 || sw.gid != -1 {
+		t.Fatalf("ids changed despite error: uid=%d gid=%d", sw.uid, sw.gid)
+	}
+}
+
+func TestBecomeUser_ExplicitSupplementaryGroups(t *testing.T) {
+	saved := captureBecomeUserFns()
+	defer restoreBecomeUserFns(saved)
+
+	u := &user.User{Username: "svc", Uid: "101", Gid: "201", HomeDir: "/tmp/svc"}
+	lookupUserFn = func(_ string) (*user.User, error) { return u, nil }
+	lookupGroupFn = func(name string) (*user.Group, error) {
+		if name != "ssl-cert" {
+			return nil, user.UnknownGroupError(name)
+		}
+		return &user.Group{Name: name, Gid: "110"}, nil
+	}
+	groupIDsFn = func(_ *user.User) ([]string, error) {
+		t.Fatal("groupIDsFn called despite explicit groups")
+		return nil, nil
+	}
+	sw := installStubSwitch(1000)
+
+	if err := BecomeUser("svc", "ssl-cert", "300"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sw.groups, []int{110, 300}) {
+		t.Fatalf("groups=%v want [110 300]", sw.groups)
+	}
+
+	sw = installStubSwitch(0)
+	err := BecomeUser("svc", "no-such-group")
+	if !errors.Is(err, ErrBecomeUser) || !errors.As(err, new(user.UnknownGroupError)) {
+		t.Fatalf("BecomeUser() = %v, want unknown group error", err)
+	}
+	if sw.groups != nil || sw.gid != -1 {
+		t.Fatalf("ids changed despite error: groups=%v gid=%d", sw.groups, sw.gid)
+	}
+}
+
+func TestUserIdentitySetEnv_SetsExistingRuntimeDir(t *testing.T) {
+	saved := captureBecomeUserFns()
+	defer restoreBecomeUserFns(saved)
+
+	userRuntimeDirBase = t.TempDir()
+	runtimeDir := filepath.Join(userRuntimeDirBase, "101")
+	if err := os.Mkdir(runtimeDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	sw := installStubSwitch(0)
+	if _, err := (userIdentity{name: "svc", home: "/tmp/svc", uid: 101}).setEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sw.env["XDG_RUNTIME_DIR"]; got != runtimeDir {
+		t.Fatalf("XDG_RUNTIME_DIR = %q, want %q", got, runtimeDir)
+	}
+}
+
+func TestUserIdentitySetEnv_RestoreUndoesChanges(t *testing.T) {
+	t.Setenv("HOME", "/orig/home")
+	t.Setenv("USER", "orig")
+	t.Setenv("XDG_DATA_HOME", "/orig/data")
+	t.Setenv("XDG_CACHE_HOME", "")
+	os.Unsetenv("XDG_CACHE_HOME")
+	restore, err := (userIdentity{name: "svc", home: "/tmp/svc", uid: 101}).setEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv("HOME") != "/tmp/svc" || os.Getenv("XDG_DATA_HOME") != "" {
+		t.Fatalf("setEnv() left HOME = %q, XDG_DATA_HOME = %q", os.Getenv("HOME"), os.Getenv("XDG_DATA_HOME"))
+	}
+	restore()
+	if os.Getenv("HOME") != "/orig/home" || os.Getenv("USER") != "orig" || os.Getenv("XDG_DATA_HOME") != "/orig/data" {
+		t.Fatalf("restore() left HOME = %q, USER = %q, XDG_DATA_HOME = %q", os.Getenv("HOME"), os.Getenv("USER"), os.Getenv("XDG_DATA_HOME"))
+	}
+	if _, ok := os.LookupEnv("XDG_CACHE_HOME"); ok {
+		t.Fatal("restore() set XDG_CACHE_HOME, which was unset")
+	}
+}
+
+func TestConfigListen_FailureBeforeUserSwitchRestoresEnv(t *testing.T) {
+	saved := captureBecomeUserFns()
+	defer restoreBecomeUserFns(saved)
+	lookupUserFn = func(string) (*user.User, error) {
+		return &user.User{Username: "svc", Uid: "4242", Gid: "4242", HomeDir: "/nonexistent/svc"}, nil
+	}
+	groupIDsFn = func(*user.User) ([]string, error) { return nil, nil }
+	t.Setenv("HOME", "/orig/home")
+	t.Setenv("USER", "orig")
+
+	cfg := &Config{Address: "127.0.0.1:0", User: "svc", Chroot: t.TempDir(), DataDir: t.TempDir()}
+	if _, err := cfg.Listen(); !errors.Is(err, ErrChroot) {
+		t.Fatalf("Listen() = %v, want %v", err, ErrChroot)
+	}
+	if os.Getenv("HOME") != "/orig/home" || os.Getenv("USER") != "orig" {
+		t.Fatalf("HOME = %q, USER = %q after failed Listen, want the original environment", os.Getenv("HOME"), os.Getenv("USER"))
+	}
+}
@@ -4,6 +4,7 @@ package webserv_test
 
 import (
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/linkdata/webserv"
@@ -19,3 +20,17 @@ func TestBecomeUser_CurrentUserSucceeds(t *testing.T) {
 		}
 	}
 }
+
+// TestBecomeUser_CurrentNumericIDsSucceeds verifies that "uid:gid" for the
+// current process ids, with the current group as the only supplementary group
+// when root, is accepted.
+func TestBecomeUser_CurrentNumericIDsSucceeds(t *testing.T) {
+	gid := strconv.Itoa(os.Getegid())
+	var groups []string
+	if os.Geteuid() == 0 {
+		groups = []string{gid}
+	}
+	if err := webserv.BecomeUser(strconv.Itoa(os.Geteuid())+":"+gid, groups...); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,34 @@
+package webserv
+
+const (
+	capSetpcap        = 8
+	capNetBindService = 10
+)
+
+// canBindPrivilegedPorts reports whether a process with the given effective
+// user id may bind ports below 1024, either as root or, on Linux, by holding
+// CAP_NET_BIND_SERVICE from file or ambient capabilities.
+func canBindPrivilegedPorts(euid int) bool {
+	return euid == 0 || hasCapabilityFn(capNetBindService)
+}
+
+// DropCapabilities irrevocably gives up all Linux capabilities.
+//
+// It drops the bounding set (if CAP_SETPCAP is still held), clears the
+// ambient, inheritable, permitted and effective sets of every thread and sets
+// no_new_privs so that executing setuid or file-capability binaries cannot
+// regain privileges. It then verifies that no capability remains.
+//
+// Call it after opening listeners and switching user. To also drop the
+// bounding set when switching away from root, use [Config.DropCapabilities]
+// which handles the ordering.
+//
+// Changing the capabilities of every thread is not possible in binaries that
+// use cgo; build with CGO_ENABLED=0 (or the osusergo and netgo build tags).
+//
+// Returns an error matching [ErrDropCapabilities] on failure, which also
+// matches [errors.ErrUnsupported] on platforms other than Linux or when cgo
+// prevents the change.
+func DropCapabilities() error {
+	return newErrDropCapabilities(dropCapabilities())
+}
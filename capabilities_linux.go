@@ -0,0 +1,161 @@
+//go:build linux
+
+package webserv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	linuxCapabilityVersion3 = 0x20080522
+	prCapbsetRead           = 23
+	prCapbsetDrop           = 24
+	prSetNoNewPrivs         = 38
+	prGetNoNewPrivs         = 39
+	prCapAmbient            = 47
+	prCapAmbientIsSet       = 1
+	prCapAmbientClearAll    = 4
+)
+
+type capUserHeader struct {
+	version uint32
+	pid     int32
+}
+
+type capUserData struct {
+	effective   uint32
+	permitted   uint32
+	inheritable uint32
+}
+
+type capSets [2]capUserData
+
+var (
+	capGetFn          = capGet
+	capSetFn          = capSet
+	prctlFn           = prctl
+	prctlAllThreadsFn = prctlAllThreads
+	capLastCapFn      = capLastCap
+	hasCapabilityFn   = hasCapability
+)
+
+func capGet() (sets capSets, err error) {
+	hdr := capUserHeader{version: linuxCapabilityVersion3}
+	if _, _, e := syscall.RawSyscall(syscall.SYS_CAPGET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&sets[0])), 0); e != 0 {
+		err = os.NewSyscallError("capget", e)
+	}
+	return
+}
+
+// capSet sets the capability sets of every thread in the process. It fails
+// with [syscall.ENOTSUP] in binaries that use cgo, since the Go runtime
+// cannot then reach all threads.
+func capSet(sets capSets) (err error) {
+	hdr := capUserHeader{version: linuxCapabilityVersion3}
+	if _, _, e := syscall.AllThreadsSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&sets[0])), 0); e != 0 {
+		err = os.NewSyscallError("capset", e)
+	}
+	return
+}
+
+func prctl(option, arg2, arg3 uintptr) (r uintptr, err error) {
+	var e syscall.Errno
+	if r, _, e = syscall.RawSyscall(syscall.SYS_PRCTL, option, arg2, arg3); e != 0 {
+		err = os.NewSyscallError("prctl", e)
+	}
+	return
+}
+
+func prctlAllThreads(option, arg2, arg3 uintptr) (err error) {
+	if _, _, e := syscall.AllThreadsSyscall(syscall.SYS_PRCTL, option, arg2, arg3); e != 0 {
+		err = os.NewSyscallError("prctl", e)
+	}
+	return
+}
+
+func capLastCap() (last int, err error) {
+	var b []byte
+	if b, err = os.ReadFile(filepath.Join(procRoot, "sys/kernel/cap_last_cap")); err == nil {
+		last, err = strconv.Atoi(strings.TrimSpace(string(b)))
+	}
+	return
+}
+
+// hasCapability reports whether capability c is in the effective set of the
+// calling thread.
+func hasCapability(c int) (yes bool) {
+	if sets, err := capGetFn(); err == nil {
+		yes = sets[c/32].effective&(1<<(c%32)) != 0
+	}
+	return
+}
+
+// dropBoundingSet removes every capability from the bounding set so that no
+// future execve can regain them. It needs CAP_SETPCAP, so it must run before
+// switching away from root; it does nothing if CAP_SETPCAP is not held.
+func dropBoundingSet() (err error) {
+	if hasCapabilityFn(capSetpcap) {
+		var last int
+		if last, err = capLastCapFn(); err == nil {
+			for c := 0; c <= last && err == nil; c++ {
+				err = prctlAllThreadsFn(prCapbsetDrop, uintptr(c), 0)
+			}
+		}
+	}
+	return
+}
+
+// dropCapabilities clears the ambient, inheritable, permitted and effective
+// capability sets, dropping the bounding set first if still possible, and
+// sets no_new_privs. It then verifies that no capability remains and that
+// no_new_privs is in effect.
+func dropCapabilities() (err error) {
+	if err = dropBoundingSet(); err == nil {
+		if err = prctlAllThreadsFn(prCapAmbient, prCapAmbientClearAll, 0); err == nil {
+			if err = capSetFn(capSets{}); err == nil {
+				if err = prctlAllThreadsFn(prSetNoNewPrivs, 1, 0); err == nil {
+					err = verifyCapabilitiesDropped()
+				}
+			}
+		}
+	}
+	if errors.Is(err, syscall.ENOTSUP) {
+		err = fmt.Errorf("%w: binaries using cgo cannot change capabilities of all threads; build with CGO_ENABLED=0: %w", errors.ErrUnsupported, err)
+	}
+	return
+}
+
+func verifyCapabilitiesDropped() (err error) {
+	var sets capSets
+	if sets, err = capGetFn(); err == nil {
+		for i := 0; i < len(sets) && err == nil; i++ {
+			if sets[i] != (capUserData{}) {
+				err = fmt.Errorf("capabilities still present: %+v", sets)
+			}
+		}
+	}
+	var last int
+	if err == nil {
+		last, err = capLastCapFn()
+	}
+	for c := 0; c <= last && err == nil; c++ {
+		var r uintptr
+		if r, err = prctlFn(prCapAmbient, prCapAmbientIsSet, uintptr(c)); err == nil && r != 0 {
+			err = fmt.Errorf("ambient capability %d still set", c)
+		}
+	}
+	if err == nil {
+		var r uintptr
+		if r, err = prctlFn(prGetNoNewPrivs, 0, 0); err == nil && r != 1 {
+			err = errors.New("no_new_privs not set")
+		}
+	}
+	return
+}
@@ -0,0 +1,218 @@
+//go:build linux
+
+package webserv
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+type capabilityFns struct {
+	capGetFn          func() (capSets, error)
+	capSetFn          func(capSets) error
+	prctlFn           func(option, arg2, arg3 uintptr) (uintptr, error)
+	prctlAllThreadsFn func(option, arg2, arg3 uintptr) error
+	capLastCapFn      func() (int, error)
+	hasCapabilityFn   func(int) bool
+}
+
+func captureCapabilityFns() capabilityFns {
+	return capabilityFns{
+		capGetFn:          capGetFn,
+		capSetFn:          capSetFn,
+		prctlFn:           prctlFn,
+		prctlAllThreadsFn: prctlAllThreadsFn,
+		capLastCapFn:      capLastCapFn,
+		hasCapabilityFn:   hasCapabilityFn,
+	}
+}
+
+func restoreCapabilityFns(fns capabilityFns) {
+	capGetFn = fns.capGetFn
+	capSetFn = fns.capSetFn
+	prctlFn = fns.prctlFn
+	prctlAllThreadsFn = fns.prctlAllThreadsFn
+	capLastCapFn = fns.capLastCapFn
+	hasCapabilityFn = fns.hasCapabilityFn
+}
+
+// fakeCapabilityKernel models the per-process capability state touched by
+// dropCapabilities.
+type fakeCapabilityKernel struct {
+	sets      capSets
+	bounding  uint64
+	ambient   uint64
+	noNewPriv bool
+	ignoreSet bool
+}
+
+func (k *fakeCapabilityKernel) install() {
+	capGetFn = func() (capSets, error) { return k.sets, nil }
+	capSetFn = func(sets capSets) error {
+		if !k.ignoreSet {
+			k.sets = sets
+		}
+		return nil
+	}
+	capLastCapFn = func() (int, error) { return 40, nil }
+	hasCapabilityFn = func(c int) bool { return k.sets[c/32].effective&(1<<(c%32)) != 0 }
+	prctlFn = func(option, arg2, arg3 uintptr) (r uintptr, err error) {
+		switch option {
+		case prCapbsetRead:
+			r = uintptr(k.bounding >> arg2 & 1)
+		case prCapAmbient:
+			r = uintptr(k.ambient >> arg3 & 1)
+		case prGetNoNewPrivs:
+			if k.noNewPriv {
+				r = 1
+			}
+		}
+		return
+	}
+	prctlAllThreadsFn = func(option, arg2, _ uintptr) (err error) {
+		switch option {
+		case prCapbsetDrop:
+			k.bounding &^= 1 << arg2
+		case prCapAmbient:
+			if arg2 == prCapAmbientClearAll {
+				k.ambient = 0
+			}
+		case prSetNoNewPrivs:
+			k.noNewPriv = arg2 == 1
+		}
+		return
+	}
+}
+
+func fullCapabilityKernel() *fakeCapabilityKernel {
+	return &fakeCapabilityKernel{
+		sets: capSets{
+			{effective: ^uint32(0), permitted: ^uint32(0), inheritable: 1 << capNetBindService},
+			{effective: 0x1ff, permitted: 0x1ff},
+		},
+		bounding: 1<<41 - 1,
+		ambient:  1 << capNetBindService,
+	}
+}
+
+func TestDropCapabilities_ClearsEverything(t *testing.T) {
+	saved := captureCapabilityFns()
+	defer restoreCapabilityFns(saved)
+	k := fullCapabilityKernel()
+	k.install()
+
+	if err := DropCapabilities(); err != nil {
+		t.Fatal(err)
+	}
+	if k.sets != (capSets{}) || k.bounding != 0 || k.ambient != 0 || !k.noNewPriv {
+		t.Fatalf("capabilities not fully dropped: %+v", k)
+	}
+}
+
+func TestDropCapabilities_WithoutSetpcapKeepsBoundingSet(t *testing.T) {
+	saved := captureCapabilityFns()
+	defer restoreCapabilityFns(saved)
+	k := fullCapabilityKernel()
+	k.sets[0].effective &^= 1 << capSetpcap
+	k.install()
+
+	if err := DropCapabilities(); err != nil {
+		t.Fatal(err)
+	}
+	if k.bounding == 0 {
+		t.Fatal("bounding set dropped without CAP_SETPCAP")
+	}
+	if k.sets != (capSets{}) || !k.noNewPriv {
+		t.Fatalf("capabilities not dropped: %+v", k)
+	}
+}
+
+func TestDropCapabilities_VerificationDetectsRemainingCapabilities(t *testing.T) {
+	saved := captureCapabilityFns()
+	defer restoreCapabilityFns(saved)
+	k := fullCapabilityKernel()
+	k.ignoreSet = true
+	k.install()
+
+	err := DropCapabilities()
+	if !errors.Is(err, ErrDropCapabilities) {
+		t.Fatalf("DropCapabilities() = %v, want match %v", err, ErrDropCapabilities)
+	}
+	if !strings.Contains(err.Error(), "still present") {
+		t.Fatalf("DropCapabilities() = %v, want verification failure", err)
+	}
+}
+
+func TestDropCapabilities_CgoIsUnsupported(t *testing.T) {
+	saved := captureCapabilityFns()
+	defer restoreCapabilityFns(saved)
+	k := fullCapabilityKernel()
+	k.install()
+	prctlAllThreadsFn = func(_, _, _ uintptr) error { return os.NewSyscallError("prctl", syscall.ENOTSUP) }
+
+	err := DropCapabilities()
+	if !errors.Is(err, ErrDropCapabilities) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("DropCapabilities() = %v, want match %v and %v", err, ErrDropCapabilities, errors.ErrUnsupported)
+	}
+}
+
+func TestHasCapability_MatchesProcStatus(t *testing.T) {
+	b, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		t.Skipf("procfs unavailable: %v", err)
+	}
+	var capEff uint64
+	for line := range strings.Lines(string(b)) {
+		if hex, ok := strings.CutPrefix(line, "CapEff:"); ok {
+			if capEff, err = strconv.ParseUint(strings.TrimSpace(hex), 16, 64); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, c := range []int{capSetpcap, capNetBindService} {
+		if got, want := hasCapability(c), capEff&(1<<c) != 0; got != want {
+			t.Errorf("hasCapability(%d) = %v, want %v (CapEff %x)", c, got, want, capEff)
+		}
+	}
+}
+
+// TestDropCapabilities_Process drops capabilities for real in a child process,
+// since the change is irreversible. Binaries using cgo cannot do this, in which
+// case the child reports errors.ErrUnsupported and the test is skipped.
+func TestDropCapabilities_Process(t *testing.T) {
+	if os.Getenv("WEBSERV_DROP_CAPABILITIES_CHILD") == "1" {
+		err := DropCapabilities()
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			os.Exit(3)
+		case err != nil:
+			t.Fatal(err)
+		}
+		status, err := os.ReadFile("/proc/self/status")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"CapEff:\t0000000000000000", "CapPrm:\t0000000000000000", "CapAmb:\t0000000000000000", "NoNewPrivs:\t1"} {
+			if !strings.Contains(string(status), want) {
+				t.Fatalf("missing %q in status:\n%s", want, status)
+			}
+		}
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestDropCapabilities_Process$")
+	cmd.Env = append(os.Environ(), "WEBSERV_DROP_CAPABILITIES_CHILD=1")
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 3 {
+		t.Skip("capabilities cannot be changed for all threads in this binary (cgo)")
+	}
+	if err != nil {
+		t.Fatalf("child failed: %v\n%s", err, output)
+	}
+}
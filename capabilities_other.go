@@ -0,0 +1,15 @@
+//go:build !linux
+
+package webserv
+
+import "errors"
+
+var hasCapabilityFn = func(int) bool { return false }
+
+func dropBoundingSet() error {
+	return errors.ErrUnsupported
+}
+
+func dropCapabilities() error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package webserv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestDropCapabilities_UnsupportedOS(t *testing.T) {
+	err := webserv.DropCapabilities()
+	if !errors.Is(err, webserv.ErrDropCapabilities) {
+		t.Errorf("error %v does not match webserv.ErrDropCapabilities", err)
+	}
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("error %v does not match errors.ErrUnsupported", err)
+	}
+}
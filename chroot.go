@@ -0,0 +1,56 @@
+package webserv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+var errOutsideChroot = errors.New("data directory is outside the chroot directory")
+
+// pathInChroot returns p as seen from inside a chroot at root, and whether p is
+// inside root at all. Both paths must be absolute; the test is lexical, so a
+// symlink inside root that points outside it is not detected.
+func pathInChroot(root, p string) (inside string, ok bool) {
+	if rel, err := filepath.Rel(root, p); err == nil && filepath.IsLocal(rel) {
+		inside, ok = filepath.Join(string(filepath.Separator), rel), true
+	}
+	return
+}
+
+// chroot confines the process to cfg.Chroot, if set, and rewrites cfg.DataDir,
+// cfg.CertDir and the other directories to their paths inside it. A non-empty
+// cfg.DataDir must be inside cfg.Chroot. cfg.CertDir is left unchanged if it is
+// outside, since the certificates have already been loaded. The other
+// directories are cleared with a warning if they are outside, since they
+// would not be reachable.
+func (cfg *Config) chroot() (err error) {
+	if cfg.Chroot != "" {
+		dir := os.ExpandEnv(cfg.Chroot)
+		if dir, err = filepath.Abs(dir); err == nil {
+			dataDir, ok := pathInChroot(dir, cfg.DataDir)
+			if cfg.DataDir != "" && !ok {
+				err = newErrDataDir(cfg.DataDir, errOutsideChroot)
+			} else if err = chroot(dir); err == nil {
+				if cfg.DataDir != "" {
+					cfg.DataDir = dataDir
+				}
+				if certDir, ok := pathInChroot(dir, cfg.CertDir); ok && cfg.CertDir != "" {
+					cfg.CertDir = certDir
+				}
+				for _, d := range cfg.userDirs() {
+					if *d.dir != "" {
+						inside, ok := pathInChroot(dir, *d.dir)
+						if !ok {
+							cfg.logWarn(d.name+" outside chroot", "dir", *d.dir)
+						}
+						*d.dir = inside
+					}
+				}
+				cfg.logInfo("chroot", "dir", dir)
+			}
+		}
+		err = newErrChroot(dir, err)
+	}
+	return
+}
@@ -0,0 +1,9 @@
+//go:build !(unix || linux)
+
+package webserv
+
+import "errors"
+
+func chroot(string) error {
+	return errors.ErrUnsupported
+}
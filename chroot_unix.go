@@ -0,0 +1,20 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"os"
+	"syscall"
+)
+
+var chrootFn = syscall.Chroot
+
+// chroot changes the root directory of the process to dir and then changes
+// the working directory to the new root, so no directory outside remains
+// reachable through the working directory.
+func chroot(dir string) (err error) {
+	if err = chrootFn(dir); err == nil {
+		err = os.Chdir("/")
+	}
+	return
+}
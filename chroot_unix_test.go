@@ -0,0 +1,161 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestPathInChroot(t *testing.T) {
+	for _, tc := range []struct {
+		root, p string
+		want    string
+		wantOK  bool
+	}{
+		{root: "/srv/app", p: "/srv/app", want: "/", wantOK: true},
+		{root: "/srv/app", p: "/srv/app/data", want: "/data", wantOK: true},
+		{root: "/srv/app", p: "/srv/app/data/../certs", want: "/certs", wantOK: true},
+		{root: "/srv/app", p: "/srv/application", wantOK: false},
+		{root: "/srv/app", p: "/srv/app/../etc", wantOK: false},
+		{root: "/srv/app", p: "/etc/letsencrypt", wantOK: false},
+		{root: "/", p: "/var/lib/app", want: "/var/lib/app", wantOK: true},
+	} {
+		got, ok := pathInChroot(tc.root, tc.p)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("pathInChroot(%q, %q) = (%q, %v), want (%q, %v)", tc.root, tc.p, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestConfigChroot_RewritesPaths(t *testing.T) {
+	saved := chrootFn
+	defer func() { chrootFn = saved }()
+	t.Chdir(t.TempDir())
+
+	root := t.TempDir()
+	var gotDir string
+	chrootFn = func(dir string) error {
+		gotDir = dir
+		return nil
+	}
+	cfg := &Config{
+		Chroot:  root,
+		DataDir: filepath.Join(root, "data"),
+		CertDir: "/etc/letsencrypt/live/example.com",
+	}
+	if err := cfg.chroot(); err != nil {
+		t.Fatal(err)
+	}
+	if gotDir != root {
+		t.Fatalf("chroot(%q), want %q", gotDir, root)
+	}
+	if cfg.DataDir != "/data" {
+		t.Fatalf("DataDir = %q, want %q", cfg.DataDir, "/data")
+	}
+	if cfg.CertDir != "/etc/letsencrypt/live/example.com" {
+		t.Fatalf("CertDir outside chroot was changed to %q", cfg.CertDir)
+	}
+
+	cfg = &Config{Chroot: root, CertDir: filepath.Join(root, "certs")}
+	if err := cfg.chroot(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CertDir != "/certs" || cfg.DataDir != "" {
+		t.Fatalf("CertDir = %q, DataDir = %q", cfg.CertDir, cfg.DataDir)
+	}
+}
+
+func TestConfigChroot_DataDirOutsideFails(t *testing.T) {
+	saved := chrootFn
+	defer func() { chrootFn = saved }()
+
+	chrootFn = func(string) error {
+		t.Fatal("chroot called with data directory outside")
+		return nil
+	}
+	root := t.TempDir()
+	cfg := &Config{Chroot: root, DataDir: t.TempDir()}
+	err := cfg.chroot()
+	if !errors.Is(err, ErrChroot) || !errors.Is(err, ErrDataDir) || !errors.Is(err, errOutsideChroot) {
+		t.Fatalf("chroot() = %v, want match %v and %v", err, ErrChroot, ErrDataDir)
+	}
+	var ce ChrootError
+	if !errors.As(err, &ce) || ce.Dir != root {
+		t.Fatalf("chroot() = %#v, want ChrootError for %q", err, root)
+	}
+}
+
+// TestConfigListen_ChrootProcess runs Listen with Chroot and User in a child
+// process, since neither can be undone. It checks that the user is looked up
+// before the chroot (the chroot contains no passwd database) and that the data
+// directory is created inside it as the new user.
+func TestConfigListen_ChrootProcess(t *testing.T) {
+	if root := os.Getenv("WEBSERV_CHROOT_CHILD"); root != "" {
+		cfg := &Config{
+			Address:     "127.0.0.1:0",
+			Chroot:      root,
+			User:        "nobody",
+			DataDir:     filepath.Join(root, "data"),
+			DataDirMode: 0o700,
+		}
+		l, err := cfg.Listen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = l.Close() }()
+		if cfg.DataDir != "/data" {
+			t.Fatalf("DataDir = %q, want %q", cfg.DataDir, "/data")
+		}
+		st, err := os.Stat("/data")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !st.IsDir() {
+			t.Fatal("/data is not a directory")
+		}
+		if _, err = os.Stat("/marker"); err != nil {
+			t.Fatalf("not inside chroot: %v", err)
+		}
+		return
+	}
+
+	if os.Geteuid() != 0 {
+		t.Skip("chroot requires root")
+	}
+	u, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skipf("no nobody user: %v", err)
+	}
+	root, err := os.MkdirTemp("", "webserv-chroot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(root) }()
+	if err = os.WriteFile(filepath.Join(root, "marker"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// nobody must be able to create /data inside the new root.
+	if err = os.Chmod(root, 0o777); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestConfigListen_ChrootProcess$")
+	cmd.Env = append(os.Environ(), "WEBSERV_CHROOT_CHILD="+root)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("child failed: %v\n%s", err, output)
+	}
+	st, err := os.Stat(filepath.Join(root, "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid := strconv.FormatUint(uint64(st.Sys().(*syscall.Stat_t).Uid), 10); uid != u.Uid {
+		t.Fatalf("data directory owned by uid %s, want %s", uid, u.Uid)
+	}
+}
@@ -2,36 +2,82 @@ package webserv
 
 import (
 	"context"
-	"errors"
 	"io/fs"
 	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 )
 
 const defaultShutdownTimeLimit = time.Second
 
+// defaultDataDirForbiddenPerm rejects group and world write access.
+const defaultDataDirForbiddenPerm fs.FileMode = 0o022
+
 // Config contains the startup and serving settings for a simple web service.
 //
 // The zero value is usable: [Config.Listen] serves HTTP on the default address
 // and port, [Config.Serve] uses a default [net/http.Server], no user switch or
 // data directory setup is performed, and no logs are emitted.
+//
+// A Config may be copied as a value before [Config.Listen]. Each call to
+// Listen starts new runtime state (the lifecycle state, lock file and
+// certificates), which copies made after that share.
 type Config struct {
 	Address              string        // optional specific address to listen on; use ":port" for port-only
-	CertDir              string        // if set, directory to look for fullchain.pem and privkey.pem
+	CertDir              string        // if set, directory to look for fullchain.pem and privkey.pem; if unset, CREDENTIALS_DIRECTORY is used when it holds them
 	FullchainPem         string        // set to override filename for "fullchain.pem"
 	PrivkeyPem           string        // set to override filename for "privkey.pem"
-	User                 string        // if set, user to switch to after opening listening port
+	StrictPaths          bool          // if set, FullchainPem and PrivkeyPem must resolve inside CertDir and DefaultDataDirSuffix inside its base directory, even through symlinks
+	User                 string        // if set, user to switch to after opening listening port, as "user", "user:group", "uid" or "uid:gid"
+	Groups               []string      // if set, supplementary groups (names or ids) to use after switching user instead of the user's own
+	DropCapabilities     bool          // if set, drop all Linux capabilities and set no_new_privs at the end of Listen
+	Chroot               string        // if set, directory to chroot into after opening listening port and before switching user (Unix only, requires root); must contain DataDir
+	Landlock             bool          // if set, restrict filesystem access with Landlock at the end of Listen to DataDir (read-write), CertDir (read-only) and the paths below (Linux only)
+	LandlockReadPaths    []string      // additional paths Landlock allows read-only access beneath, such as "/etc/resolv.conf"
+	LandlockWritePaths   []string      // additional paths Landlock allows read-write access beneath
 	DataDir              string        // if set, the data directory to use (created only when DataDirMode is nonzero); if unset, may be filled in after Listen
 	DefaultDataDirSuffix string        // if set and DataDir is not set, set DataDir to the user's default data dir plus this suffix
 	DataDirMode          fs.FileMode   // if nonzero, create DataDir if it does not exist using this mode (subject to the process umask, like os.MkdirAll)
+	DataDirXDG           bool          // if set and DataDir is unset, default DataDir under UserDataDir (XDG_DATA_HOME) rather than the user config directory
+	ConfigDir            string        // if set, the configuration directory; if unset, CONFIGURATION_DIRECTORY or, given DefaultDataDirSuffix and ConfigDirMode, os.UserConfigDir plus the suffix
+	ConfigDirMode        fs.FileMode   // if nonzero, create ConfigDir if it does not exist using this mode
+	StateDir             string        // if set, the state directory; if unset, STATE_DIRECTORY or, given DefaultDataDirSuffix and StateDirMode, UserStateDir (XDG_STATE_HOME) plus the suffix
+	StateDirMode         fs.FileMode   // if nonzero, create StateDir if it does not exist using this mode
+	CacheDir             string        // if set, the cache directory; if unset, CACHE_DIRECTORY or, given DefaultDataDirSuffix and CacheDirMode, os.UserCacheDir (XDG_CACHE_HOME) plus the suffix
+	CacheDirMode         fs.FileMode   // if nonzero, create CacheDir if it does not exist using this mode
+	RuntimeDir           string        // if set, the runtime directory; if unset, RUNTIME_DIRECTORY or, given DefaultDataDirSuffix and RuntimeDirMode, XDG_RUNTIME_DIR plus the suffix (left empty without either)
+	RuntimeDirMode       fs.FileMode   // if nonzero, create RuntimeDir if it does not exist using this mode
+	DataDirCheck         bool          // if set, Listen fails unless DataDir is a directory owned by the effective user without DataDirForbiddenPerm bits
+	DataDirForbiddenPerm fs.FileMode   // permission bits DataDir must not have when DataDirCheck is set; zero means 0o022 (group or world writable)
+	DataDirChown         bool          // if set, DataDir is created while still root and given to User, rather than created after the user switch
+	DataDirProbe         bool          // if set, Listen verifies that DataDir is writable by creating and removing a file in it after switching user
+	DataDirMinFreeBytes  uint64        // if nonzero, Listen fails if the filesystem holding DataDir has less space available than this
+	DataDirMinFreeInodes uint64        // if nonzero, Listen fails if the filesystem holding DataDir has fewer free inodes than this
+	DataDirMonitor       time.Duration // if nonzero, ServeWith repeats the DataDir checks above at this interval, logging a warning when they start failing
+	LockFile             string        // if set, name of a file in DataDir that Listen locks exclusively, failing if another instance holds it; released when ServeWith returns
+	PIDFile              string        // if set, name of a file in DataDir that Listen writes the process ID to; removed when ServeWith returns
 	ListenURL            string        // if set, the external URL clients can reach us at. If unset, Listen may fill this in (e.g. "https://localhost:8443"), even when Listen later returns an error after binding.
-	ShutdownTimeLimit    time.Duration // maximum time ServeWith waits for graceful shutdown; zero uses a 1 second default
+	Umask                fs.FileMode   // if nonzero, process umask to set at the start of Listen (Unix only)
+	MaxOpenFiles         uint64        // if nonzero, set RLIMIT_NOFILE to this at the start of Listen, raising the hard limit if needed (Unix only)
+	DisableCoreDumps     bool          // if set, set RLIMIT_CORE soft and hard limits to zero at the start of Listen (Unix only)
+	DrainDelay           time.Duration // if nonzero, ServeWith keeps serving this long after a shutdown is requested, with Ready reporting false, before shutting down; canceling its context skips or ends the delay
+	ShutdownTimeLimit    time.Duration // maximum time ServeWith waits for graceful shutdown before closing remaining connections; zero uses a 1 second default
+	DumpSignal           os.Signal     // if set, ServeWith calls Dump on this signal (such as SIGQUIT or SIGUSR1) unless Signals maps it otherwise
+	DisableSignals       bool          // if set, ServeWith handles no signals at all, leaving them to the application
 	LogTLSErrors         bool          // if set, http.Server TLS handshake error messages are not filtered
 	Logger               Logger        // logger to use, if nil logs nothing
+
+	Signals map[os.Signal]SignalAction // if not nil, the signals ServeWith handles and what it does on each, replacing DefaultSignals
+
+	OnListening     func(ctx context.Context) error // if set, called by ServeWith once serving has started; an error shuts the server down again
+	OnShutdownStart func(ctx context.Context) error // if set, called by ServeWith when a shutdown is requested, before DrainDelay
+	OnDrained       func(ctx context.Context) error // if set, called by ServeWith after the shutdown has drained or closed the connections
+	OnStopped       func(ctx context.Context) error // if set, called last by ServeWith, after srv.Serve has returned
+	OnReload        func(ctx context.Context) error // if set, called by Reload after reloading certificates and reopening the logger
+	OnStateChange   func(t Transition)              // if set, called synchronously and in order on every lifecycle state change; must not block
+
+	inst *instance // runtime state, created by Listen or on first use
 }
 
 func (cfg *Config) logInfo(msg string, keyValuePairs ...any) {
@@ -40,6 +86,45 @@ func (cfg *Config) logInfo(msg string, keyValuePairs ...any) {
 	}
 }
 
+func (cfg *Config) logWarn(msg string, keyValuePairs ...any) {
+	if cfg.Logger != nil {
+		cfg.Logger.Warn("webserv: "+msg, keyValuePairs...)
+	}
+}
+
+func (cfg *Config) logError(msg string, keyValuePairs ...any) {
+	if cfg.Logger != nil {
+		cfg.Logger.Error("webserv: "+msg, keyValuePairs...)
+	}
+}
+
+func (cfg *Config) dataDirForbiddenPerm() (perm fs.FileMode) {
+	if perm = cfg.DataDirForbiddenPerm; perm == 0 {
+		perm = defaultDataDirForbiddenPerm
+	}
+	return
+}
+
+// switchUser changes to the ids of the target user looked up for cfg.User,
+// first creating cfg.DataDir owned by that user if cfg.DataDirChown is set.
+func (cfg *Config) switchUser(id userIdentity) (err error) {
+	if cfg.User != "" {
+		if cfg.DataDirChown && cfg.DataDirMode != 0 && cfg.DataDir != "" {
+			var created bool
+			if created, err = id.mkdirAllOwned(cfg.DataDir, cfg.DataDirMode); err == nil && created {
+				cfg.logInfo("data directory created", "dir", cfg.DataDir, "user", cfg.User)
+			}
+			err = newErrDataDir(cfg.DataDir, err)
+		}
+		if err == nil {
+			if err = newErrBecomeUser(cfg.User, id.setIDs()); err == nil {
+				cfg.logInfo("user switched", "user", cfg.User)
+			}
+		}
+	}
+	return
+}
+
 func (cfg *Config) shutdownTimeLimit() (limit time.Duration) {
 	if limit = cfg.ShutdownTimeLimit; limit == 0 {
 		limit = defaultShutdownTimeLimit
@@ -56,61 +141,235 @@ func isCleanServerClosed(err error) bool {
 // Listen performs initial setup for a simple web server and returns a
 // [net.Listener] if successful.
 //
-// First it loads certificates if cfg.CertDir is set, and then starts a [net.Listener]
+// First it sets the process umask if cfg.Umask is nonzero, and the
+// RLIMIT_NOFILE and RLIMIT_CORE resource limits if cfg.MaxOpenFiles or
+// cfg.DisableCoreDumps are set, logging the effective values. This happens
+// while still privileged, so that hard limits can be raised.
+//
+// It then loads certificates if cfg.CertDir is set (or systemd passed them in
+// CREDENTIALS_DIRECTORY, see [LoadCert]), and then starts a [net.Listener]
 // (TLS or normal). The listener will default to all addresses and standard port
-// depending on privileges and if a certificate was loaded or not.
+// depending on privileges (root, or CAP_NET_BIND_SERVICE on Linux) and if a
+// certificate was loaded or not.
 //
 // If cfg.Address was set, any address or port given there overrides these defaults.
 //
+// If cfg.StrictPaths is set, certificates are loaded with [LoadCertStrict] and
+// cfg.DefaultDataDirSuffix must stay inside the base directories it is
+// appended to, as with [DefaultDataDirStrict]; paths that escape fail with an
+// error matching [ErrPathEscape].
+//
 // If cfg.User is set it then switches to that user with [BecomeUser], dropping
-// supplementary groups, GID and UID (when running as root). Note that this is
-// not supported on Windows.
+// supplementary groups, GID and UID (when running as root). cfg.User may name
+// a group as "user:group" or use numeric ids, and cfg.Groups may list the
+// supplementary groups explicitly. Note that this is not supported on Windows.
 //
 // If cfg.DataDir or cfg.DefaultDataDirSuffix is set, calculates the absolute
-// data directory path with [DefaultDataDir] and sets cfg.DataDir. If
-// cfg.DataDirMode is nonzero, [UseDataDir] creates the directory if necessary,
-// using cfg.DataDirMode subject to the process umask.
-//
-// On return, cfg.CertDir and cfg.DataDir will be absolute paths or be empty.
-// If Listen returns an error, cfg.DataDir is reset to empty regardless of the
-// value the caller supplied, while cfg.CertDir keeps any absolute path resolved
-// before the failure.
+// data directory path with [DefaultDataDir] and sets cfg.DataDir. This happens
+// after HOME has been set for cfg.User but before the ids are changed; if
+// Listen fails before the ids are changed, HOME, USER and the XDG variables are
+// restored. If cfg.DataDirMode is nonzero, [UseDataDir] creates the directory
+// if necessary (as the new user), using cfg.DataDirMode subject to the process
+// umask. If cfg.DataDirChown is also set and Listen runs as root, the directory
+// is instead created before the ids are changed and handed to the new user, so
+// it may live where the new user could not create it. If cfg.DataDirCheck is
+// set, [CheckDataDir] then verifies that the directory is owned by the
+// effective user and has none of the cfg.DataDirForbiddenPerm bits (group or
+// world write access by default). If cfg.DataDirProbe is set, [ProbeDataDir]
+// checks that the new user can write to it, and if cfg.DataDirMinFreeBytes or
+// cfg.DataDirMinFreeInodes is set, [CheckDataDirSpace] checks its filesystem.
+//
+// cfg.ConfigDir, cfg.StateDir, cfg.CacheDir and cfg.RuntimeDir are resolved at
+// the same point, preferring the directories systemd passes in
+// CONFIGURATION_DIRECTORY, STATE_DIRECTORY, CACHE_DIRECTORY and
+// RUNTIME_DIRECTORY. Otherwise, those with a nonzero mode default to
+// cfg.DefaultDataDirSuffix appended to the XDG base directories of the new
+// user, and those with a zero mode are left empty. Those with a nonzero mode
+// are created as the new user after the data directory. If cfg.DataDirXDG is
+// set, the default data directory is based on [UserDataDir] instead of
+// [os.UserConfigDir].
+//
+// If cfg.LockFile is set, Listen then takes an exclusive lock on that file
+// inside the data directory with [AcquireLock], failing with an error matching
+// [ErrLock] that names the holder if another instance already runs there. If
+// cfg.PIDFile is set, the process ID is written to that file inside the data
+// directory. Both are released when [Config.ServeWith] returns; if
+// [Config.ServeWith] is never called, the lock is held until the process exits.
+// Both names must be local paths (see [path/filepath.IsLocal]), and with
+// cfg.StrictPaths must not lead outside the data directory through a symlink;
+// otherwise Listen fails with an error matching [ErrPathEscape].
+//
+// If cfg.Chroot is set, the process is confined to that directory after the
+// target user has been looked up and the data directory resolved, but before
+// the ids are changed. cfg.DataDir must be inside cfg.Chroot, and both
+// cfg.DataDir and (if inside) cfg.CertDir are rewritten to their paths within
+// the new root. Anything needed later, such as time zone data or CA
+// certificates for outgoing TLS, must also be present inside cfg.Chroot.
+//
+// If cfg.Landlock is set, the final step restricts filesystem access with
+// [Landlock] to read-write beneath cfg.DataDir and cfg.LandlockWritePaths and
+// read-only beneath cfg.CertDir and cfg.LandlockReadPaths, and to certificate
+// files named outside cfg.CertDir, so that [Config.Reload] can read them
+// again. Certificate paths that are no longer reachable, such as a
+// cfg.CertDir outside cfg.Chroot, are skipped with a warning. If the kernel
+// lacks Landlock, a warning is logged and Listen succeeds without the
+// restriction.
+//
+// If cfg.DropCapabilities is set, the capability bounding set is dropped before
+// switching user and [DropCapabilities] is called once the data directory is
+// set up, leaving the process without any Linux capabilities and with
+// no_new_privs set. This is only supported on Linux in binaries built without
+// cgo.
+//
+// On return, cfg.CertDir, cfg.DataDir and the other directories will be
+// absolute paths or be empty. If Listen returns an error, cfg.DataDir and the
+// other directories are reset to empty regardless of the values the caller
+// supplied, while cfg.CertDir keeps any absolute path resolved before the
+// failure.
 // If cfg.ListenURL was empty it may be set to a best-guess printable and connectable
 // URL like "http://localhost:80" as soon as the socket is opened.
 // Therefore cfg.ListenURL can be non-empty even if Listen returns an error from a
 // later step, such as user switching or data directory setup.
+//
+// Errors identify the failed stage: [ErrResourceLimits], [ErrLoadCert],
+// [ErrListen], [ErrBecomeUser], [ErrDataDir], [ErrLock], [ErrChroot],
+// [ErrDropCapabilities] or [ErrLandlock]. Use [errors.As] with [LoadCertError],
+// [ListenError], [DataDirError], [LockError] or [ChrootError] to inspect the
+// inputs of the failed stage.
+//
+// Listen moves the lifecycle state reported by [Config.State] to
+// [StateStarting], and then to [StateListening] or [StateFailed].
 func (cfg *Config) Listen() (l net.Listener, err error) {
-	if l, cfg.ListenURL, cfg.CertDir, err = Listener(cfg.Address, cfg.CertDir, cfg.FullchainPem, cfg.PrivkeyPem, cfg.ListenURL); err == nil {
+	inst := cfg.newInstance()
+	cfg.setState(StateStarting, nil)
+	if err = cfg.applyProcessLimits(); err == nil {
+		inst.certs = &certificate{}
+		l, cfg.ListenURL, cfg.CertDir, err = listener(cfg.Address, cfg.CertDir, cfg.FullchainPem, cfg.PrivkeyPem, cfg.ListenURL, cfg.StrictPaths, inst.certs)
+	}
+	if err == nil {
 		if cfg.CertDir != "" {
 			cfg.logInfo("loaded certificates", "dir", cfg.CertDir)
 		}
-		if err = BecomeUser(cfg.User); err == nil {
-			if cfg.User != "" {
-				cfg.logInfo("user switched", "user", cfg.User)
+		if cfg.DropCapabilities {
+			err = newErrDropCapabilities(dropBoundingSet())
+		}
+		// Resolve the target user and switch HOME before computing the data
+		// directory, but change ids only after the chroot, which needs root.
+		// Until the ids have changed, a failure restores the environment.
+		var id userIdentity
+		var restoreEnv func()
+		if err == nil && cfg.User != "" {
+			if id, err = lookupIdentity(cfg.User, cfg.Groups); err == nil {
+				restoreEnv, err = id.setEnv()
 			}
-			if cfg.DataDir, err = DefaultDataDir(cfg.DataDir, cfg.DefaultDataDirSuffix); err == nil {
-				if cfg.DataDir, err = UseDataDir(cfg.DataDir, cfg.DataDirMode); err == nil {
-					if cfg.DataDir != "" {
-						cfg.logInfo("data directory", "dir", cfg.DataDir)
+			err = newErrBecomeUser(cfg.User, err)
+		}
+		if err == nil {
+			if err = cfg.resolveDirs(); err == nil {
+				if err = cfg.chroot(); err == nil {
+					if err = cfg.switchUser(id); err == nil {
+						restoreEnv = nil
+						if cfg.DataDir, err = UseDataDir(cfg.DataDir, cfg.DataDirMode); err == nil {
+							if cfg.DataDir != "" {
+								cfg.logInfo("data directory", "dir", cfg.DataDir)
+								if cfg.DataDirCheck {
+									err = CheckDataDir(cfg.DataDir, cfg.dataDirForbiddenPerm())
+								}
+								if err == nil {
+									err = cfg.preflightDataDir()
+								}
+							}
+							if err == nil {
+								err = cfg.useDirs()
+							}
+							if err == nil {
+								err = cfg.lockDataDir()
+							}
+							if err == nil && cfg.DropCapabilities {
+								if err = DropCapabilities(); err == nil {
+									cfg.logInfo("capabilities dropped")
+								}
+							}
+							if err == nil && cfg.Landlock {
+								err = cfg.landlock()
+							}
+						}
 					}
 				}
 			}
 		}
+		if err != nil && restoreEnv != nil {
+			restoreEnv()
+		}
 	}
 	if err != nil {
-		cfg.DataDir = ""
+		cfg.unlockDataDir()
+		cfg.clearDirs()
 		if l != nil {
 			_ = l.Close()
 			l = nil
 		}
+		cfg.setState(StateFailed, err)
+	} else {
+		cfg.setState(StateListening, nil)
 	}
 	return
 }
 
 // ServeWith catches SIGINT and SIGTERM and calls srv.Serve(l). A controlled
-// shutdown is triggered by either of those signals or by ctx being canceled,
-// using [net/http.Server.Shutdown] bounded by [Config.ShutdownTimeLimit] (or 1
-// second when [Config.ShutdownTimeLimit] is zero).
+// shutdown is triggered by either of those signals (see [Config.Signals]) or
+// by ctx being canceled, using [net/http.Server.Shutdown] bounded by
+// [Config.ShutdownTimeLimit] (or 1 second when [Config.ShutdownTimeLimit] is
+// zero). Connections registered with [Config.TrackHijacked] are notified and
+// waited for within the same limit. If the shutdown does not complete in time,
+// [net/http.Server.Close] closes the remaining connections, including the
+// registered hijacked ones, and the shutdown error becomes a [ShutdownError]
+// reporting how many were closed.
+//
+// The lifecycle callbacks are called in this order, each with a context that
+// carries the values of ctx and a deadline of [Config.ShutdownTimeLimit] from
+// when it is called:
+//   - [Config.OnListening] once srv.Serve has been started; if it fails, the
+//     server is shut down again as if a signal had been received;
+//   - [Config.OnShutdownStart] when a shutdown is requested, after
+//     [Config.Ready] turns false and before [Config.DrainDelay];
+//   - [Config.OnDrained] once the shutdown has completed or the remaining
+//     connections have been closed;
+//   - [Config.OnStopped] last, however serving ended.
+//
+// OnShutdownStart and OnDrained are not called if srv.Serve returns on its
+// own. Callback errors are logged and joined with [errors.Join] into the
+// returned error, each prefixed with the callback's name.
+//
+// srv.ConnState is wrapped to count connections and the previous hook is
+// still called from the wrapper; the original hook is not restored, since
+// connections closed by the shutdown may still report to it.
+//
+// [Config.Ready] reports true while serving and turns false as soon as the
+// shutdown is requested. If [Config.DrainDelay] is set, srv keeps serving for
+// that long before the shutdown begins, giving load balancers time to notice
+// the instance is no longer ready. A second signal during the delay terminates
+// the process. The delay is skipped when ctx is canceled, and cut short if ctx
+// is canceled during it, since the caller asked for serving to end.
+//
+// The lifecycle state reported by [Config.State] is [StateServing] while
+// serving and [StateDraining] once the shutdown is requested. When ServeWith
+// returns it is [StateStopped], or [StateFailed] if the returned error is
+// anything other than nil or ctx.Err().
+//
+// On Unix, SIGHUP calls [Config.Reload] while serving instead of terminating
+// the process, reloading certificates, reopening the logger and calling
+// [Config.OnReload]. The outcome is logged and serving continues regardless.
+// Other platforms have no reload signal by default.
+// Likewise, if [Config.DumpSignal] is set, that signal calls [Config.Dump] to
+// write goroutine stacks and a heap profile to the data directory or the
+// logger.
+//
+// The signals above are the defaults returned by [DefaultSignals]. If
+// [Config.Signals] is not nil, it replaces them, mapping each signal to the
+// [SignalAction] to take; [Config.DumpSignal] is still added unless mapped
+// there. If [Config.DisableSignals] is set, no signals are handled at all, and
+// only ctx ends serving. Signals are handled only while ServeWith runs.
 //
 // The returned error depends on what ended serving:
 //   - a clean shutdown returns nil ([net/http.ErrServerClosed] is mapped to nil);
@@ -118,14 +377,21 @@ func (cfg *Config) Listen() (l net.Listener, err error) {
 //     also exits with a non-clean error, the errors are joined with
 //     [errors.Join]; a shutdown that then exceeds [Config.ShutdownTimeLimit]
 //     does not replace that ctx.Err();
-//   - if a signal triggered the shutdown, it returns the shutdown error (such as
-//     [context.DeadlineExceeded] when draining exceeds [Config.ShutdownTimeLimit]),
-//     otherwise the error from srv.Serve.
+//   - if a signal triggered the shutdown, it returns the shutdown error (a
+//     [ShutdownError] matching [context.DeadlineExceeded] when draining exceeds
+//     [Config.ShutdownTimeLimit]), otherwise the error from srv.Serve.
 //
 // Unless [Config.LogTLSErrors] is set, srv.ErrorLog is replaced for the lifetime
 // of the call with a filter that drops TLS handshake error lines and forwards
 // the rest; the original logger is not restored.
 //
+// If [Config.DataDirMonitor] is set, the data directory checks configured for
+// [Config.Listen] are repeated at that interval while serving, logging a
+// warning when they start failing. Serving is not interrupted.
+//
+// When ServeWith returns it removes the PID file and releases the lock file
+// taken by [Config.Listen], if any.
+//
 // Panics if ctx, srv or l is nil. Panics from srv.Serve are recovered and
 // returned as an error matching [ErrServePanic].
 func (cfg *Config) ServeWith(ctx context.Context, srv *http.Server, l net.Listener) (err error) {
@@ -138,54 +404,7 @@ func (cfg *Config) ServeWith(ctx context.Context, srv *http.Server, l net.Listen
 	if l == nil {
 		panic("webserv: nil net.Listener")
 	}
-	serveErr := make(chan error, 1)
-	sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
-	defer stop()
-	if !cfg.LogTLSErrors {
-		// Install the filter before serving so the single write to
-		// srv.ErrorLog happens-before any connection goroutine reads it.
-		installTLSErrorLogFilter(srv)
-	}
-	cfg.logInfo("listening on", "address", l.Addr(), "url", cfg.ListenURL)
-	go func() {
-		defer func() {
-			if p := recover(); p != nil {
-				serveErr <- newErrServePanic(p)
-			}
-		}()
-		serveErr <- srv.Serve(l)
-	}()
-	select {
-	case err = <-serveErr:
-	case <-sigCtx.Done():
-		err = ctx.Err()
-		var reason error
-		if reason = context.Cause(ctx); reason == nil {
-			reason = context.Cause(sigCtx)
-		}
-		stop()
-		cfg.logInfo("stopped", "reason", reason)
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.shutdownTimeLimit())
-		shutdownErr := srv.Shutdown(shutdownCtx)
-		shutdownCancel()
-		serveExitErr := <-serveErr
-		if isCleanServerClosed(serveExitErr) {
-			serveExitErr = nil
-		}
-		if err == nil {
-			if shutdownErr != nil {
-				err = shutdownErr
-			} else {
-				err = serveExitErr
-			}
-		} else if serveExitErr != nil {
-			err = errors.Join(err, serveExitErr)
-		}
-	}
-	if isCleanServerClosed(err) {
-		err = nil
-	}
-	return err
+	return cfg.ServeGroup(ctx, ServerListener{Server: srv, Listener: l})
 }
 
 // Serve creates an [net/http.Server] with reasonable defaults and calls
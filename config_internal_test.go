@@ -7,12 +7,15 @@ import (
 
 type recordingLogger struct {
 	messages []string
+	warnings []string
 }
 
 func (l *recordingLogger) Info(msg string, keyValuePairs ...any) {
 	l.messages = append(l.messages, msg)
 }
-func (l *recordingLogger) Warn(msg string, keyValuePairs ...any)  {}
+func (l *recordingLogger) Warn(msg string, keyValuePairs ...any) {
+	l.warnings = append(l.warnings, msg)
+}
 func (l *recordingLogger) Error(msg string, keyValuePairs ...any) {}
 
 func TestLogInfo_LogsWithoutKeyValuePairs(t *testing.T) {
@@ -3,12 +3,15 @@
 package webserv_test
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"sync"
 	"syscall"
 	"testing"
@@ -111,3 +114,179 @@ func runSecondSignalChild(t *testing.T) {
 		os.Exit(0)
 	}
 }
+
+func TestConfigServeWith_SIGHUPReloads(t *testing.T) {
+	signalTestMu.Lock()
+	defer signalTestMu.Unlock()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded := make(chan struct{}, 1)
+	logger := newNotifyingLogger(io.Discard, listeningLogMessage)
+	cfg := &webserv.Config{
+		Logger: logger,
+		OnReload: func(context.Context) error {
+			reloaded <- struct{}{}
+			return nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, &http.Server{}, l) }()
+
+	if err = <-signalWhenReady(ctx, logger.ready, syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloaded:
+	case <-ctx.Done():
+		t.Fatal("SIGHUP did not call OnReload")
+	}
+	select {
+	case err = <-done:
+		t.Fatalf("ServeWith returned %v after SIGHUP, want it to keep serving", err)
+	default:
+	}
+	cancel()
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("ServeWith() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestConfigServeWith_DumpSignalWritesDump(t *testing.T) {
+	signalTestMu.Lock()
+	defer signalTestMu.Unlock()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	logger := newNotifyingLogger(io.Discard, listeningLogMessage)
+	cfg := &webserv.Config{
+		DataDir:    dir,
+		DumpSignal: syscall.SIGUSR1,
+		Logger:     logger,
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, &http.Server{}, l) }()
+
+	if err = <-signalWhenReady(ctx, logger.ready, syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	var dumps []string
+	for len(dumps) == 0 && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+		dumps, _ = filepath.Glob(filepath.Join(dir, "dump-*.txt"))
+	}
+	if len(dumps) != 1 {
+		t.Fatalf("dump files = %v, want one", dumps)
+	}
+	select {
+	case err = <-done:
+		t.Fatalf("ServeWith returned %v after dump signal, want it to keep serving", err)
+	default:
+	}
+	cancel()
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("ServeWith() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestConfigServeWith_SignalsMapReplacesDefaults(t *testing.T) {
+	signalTestMu.Lock()
+	defer signalTestMu.Unlock()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded := make(chan struct{}, 1)
+	logger := newNotifyingLogger(io.Discard, listeningLogMessage)
+	cfg := &webserv.Config{
+		Logger: logger,
+		Signals: map[os.Signal]webserv.SignalAction{
+			syscall.SIGUSR2: webserv.SignalShutdown,
+			syscall.SIGHUP:  webserv.SignalIgnore,
+		},
+		OnReload: func(context.Context) error {
+			reloaded <- struct{}{}
+			return nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, &http.Server{}, l) }()
+
+	if err = <-signalWhenReady(ctx, logger.ready, syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloaded:
+		t.Fatal("ignored SIGHUP called OnReload")
+	case err = <-done:
+		t.Fatalf("ServeWith returned %v after ignored SIGHUP", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err = signalSelf(syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		t.Fatal("SIGUSR2 mapped to SignalShutdown did not stop ServeWith")
+	}
+	if err != nil {
+		t.Fatalf("ServeWith() = %v, want nil", err)
+	}
+}
+
+func TestConfigServeWith_DisableSignalsLeavesSignalsAlone(t *testing.T) {
+	signalTestMu.Lock()
+	defer signalTestMu.Unlock()
+
+	// Catch SIGUSR2 ourselves so that ServeWith leaving it alone does not
+	// terminate the test process.
+	appSig := make(chan os.Signal, 1)
+	signal.Notify(appSig, syscall.SIGUSR2)
+	defer signal.Stop(appSig)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := newNotifyingLogger(io.Discard, listeningLogMessage)
+	cfg := &webserv.Config{
+		Logger:         logger,
+		DisableSignals: true,
+		Signals:        map[os.Signal]webserv.SignalAction{syscall.SIGUSR2: webserv.SignalShutdown},
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, &http.Server{}, l) }()
+
+	if err = <-signalWhenReady(ctx, logger.ready, syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-appSig:
+	case <-ctx.Done():
+		t.Fatal("application did not receive SIGUSR2")
+	}
+	select {
+	case err = <-done:
+		t.Fatalf("ServeWith returned %v after SIGUSR2 with DisableSignals", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	cancel()
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("ServeWith() = %v, want %v", err, context.Canceled)
+	}
+}
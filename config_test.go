@@ -819,3 +819,23 @@ func TestConfigServeWith_SignalPathRecoversServerClosedPanic(t *testing.T) {
 		t.Fatalf("ServeWith() error = %v, want match %v", err, webserv.ErrServePanic)
 	}
 }
+
+func TestConfigListen_ErrorsIdentifyStage(t *testing.T) {
+	cfg := &webserv.Config{CertDir: t.TempDir()}
+	l, err := cfg.Listen()
+	if l != nil {
+		_ = l.Close()
+	}
+	if !errors.Is(err, webserv.ErrLoadCert) || errors.Is(err, webserv.ErrListen) {
+		t.Fatalf("Listen() error = %v, want match only %v", err, webserv.ErrLoadCert)
+	}
+
+	cfg = &webserv.Config{Address: "127.0.0.1:99999"}
+	l, err = cfg.Listen()
+	if l != nil {
+		_ = l.Close()
+	}
+	if !errors.Is(err, webserv.ErrListen) || errors.Is(err, webserv.ErrLoadCert) {
+		t.Fatalf("Listen() error = %v, want match only %v", err, webserv.ErrListen)
+	}
+}
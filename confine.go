@@ -0,0 +1,89 @@
+package webserv
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinks bounds the symlinks followed while resolving a path, as the
+// kernel does, so that a symlink loop fails instead of looping forever.
+const maxSymlinks = 255
+
+var errTooManySymlinks = errors.New("too many levels of symbolic links")
+
+// ConfinedPath returns the path of name inside the directory base, failing
+// with an error matching [ErrPathEscape] if name is not a local path (see
+// [path/filepath.IsLocal]) or if resolving its symlinks leads outside base.
+// Trailing components of name that do not exist yet are allowed, as is a base
+// that does not exist yet. A symlink whose target does not exist must still
+// point inside base.
+//
+// The check is made when ConfinedPath is called; to rule out a symlink being
+// swapped in afterwards, access the files through an [os.Root] opened on base
+// (as [DataDir] does).
+func ConfinedPath(base, name string) (p string, err error) {
+	err = PathEscapeError{Base: base, Path: name}
+	if filepath.IsLocal(name) {
+		var realBase string
+		if realBase, err = filepath.EvalSymlinks(base); errors.Is(err, fs.ErrNotExist) {
+			// Nothing beneath a missing base can be a symlink.
+			p, err = filepath.Join(base, name), nil
+		} else if err == nil {
+			var real string
+			if real, err = resolveSymlinks(realBase, name); err == nil {
+				if rel, relErr := filepath.Rel(realBase, real); relErr != nil || (rel != "." && !filepath.IsLocal(rel)) {
+					err = PathEscapeError{Base: base, Path: name}
+				} else {
+					p = filepath.Join(base, name)
+				}
+			}
+		}
+	}
+	return
+}
+
+// resolveSymlinks resolves name relative to the symlink-free directory dir
+// one component at a time, like [path/filepath.EvalSymlinks]. Unlike it, a
+// symlink whose target does not exist resolves to that target, and once a
+// component does not exist the rest of name is joined as is, since it cannot
+// contain symlinks.
+func resolveSymlinks(dir, name string) (real string, err error) {
+	real = dir
+	rest := name
+	for links := 0; rest != "" && err == nil; {
+		var elem string
+		elem, rest, _ = strings.Cut(rest, string(filepath.Separator))
+		switch elem {
+		case "", ".":
+		case "..":
+			real = filepath.Dir(real)
+		default:
+			next := filepath.Join(real, elem)
+			var fi fs.FileInfo
+			if fi, err = os.Lstat(next); errors.Is(err, fs.ErrNotExist) {
+				real, rest, err = filepath.Join(next, rest), "", nil
+			} else if err == nil {
+				if fi.Mode()&fs.ModeSymlink == 0 {
+					real = next
+				} else if links++; links > maxSymlinks {
+					err = &fs.PathError{Op: "resolve", Path: next, Err: errTooManySymlinks}
+				} else {
+					var target string
+					if target, err = os.Readlink(next); err == nil {
+						if filepath.IsAbs(target) {
+							// Resolve absolute targets from their root.
+							vol := filepath.VolumeName(target)
+							real = vol + string(filepath.Separator)
+							target = target[len(vol):]
+						}
+						rest = target + string(filepath.Separator) + rest
+					}
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,54 @@
+package webserv_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestConfinedPath(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(base, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	canSymlink := os.Symlink(outside, filepath.Join(base, "out")) == nil &&
+		os.Symlink("sub", filepath.Join(base, "in")) == nil &&
+		os.Symlink(filepath.Join(outside, "victim"), filepath.Join(base, "dangling")) == nil &&
+		os.Symlink(filepath.Join("..", filepath.Base(outside), "victim"), filepath.Join(base, "dangling-rel")) == nil &&
+		os.Symlink(filepath.Join("sub", "missing"), filepath.Join(base, "dangling-in")) == nil &&
+		os.Symlink("loop", filepath.Join(base, "loop")) == nil
+
+	for _, name := range []string{"file", filepath.Join("sub", "file"), filepath.Join("sub", "new", "deeper"), filepath.Join("sub", "..", "file")} {
+		if p, err := webserv.ConfinedPath(base, name); err != nil || p != filepath.Join(base, name) {
+			t.Errorf("ConfinedPath(%q) = (%q, %v), want path inside", name, p, err)
+		}
+	}
+	escapes := []string{"..", filepath.Join("..", "x"), filepath.Join("sub", "..", "..", "x"), outside, ""}
+	if canSymlink {
+		for _, name := range []string{filepath.Join("in", "file"), "dangling-in"} {
+			if p, err := webserv.ConfinedPath(base, name); err != nil {
+				t.Errorf("ConfinedPath(%q) = (%q, %v), want symlink inside accepted", name, p, err)
+			}
+		}
+		if p, err := webserv.ConfinedPath(base, "loop"); err == nil {
+			t.Errorf("ConfinedPath(loop) = %q, want error", p)
+		}
+		escapes = append(escapes, "out", filepath.Join("out", "file"), "dangling", "dangling-rel", filepath.Join("in", "..", "dangling"))
+	}
+	for _, name := range escapes {
+		p, err := webserv.ConfinedPath(base, name)
+		var pe webserv.PathEscapeError
+		if !errors.Is(err, webserv.ErrPathEscape) || !errors.As(err, &pe) || pe.Base != base || pe.Path != name {
+			t.Errorf("ConfinedPath(%q) = (%q, %v), want PathEscapeError", name, p, err)
+		}
+	}
+
+	missing := filepath.Join(base, "missing")
+	if p, err := webserv.ConfinedPath(missing, "file"); err != nil || p != filepath.Join(missing, "file") {
+		t.Errorf("ConfinedPath(missing base) = (%q, %v), want path inside", p, err)
+	}
+}
@@ -0,0 +1,175 @@
+package webserv
+
+import (
+	"errors"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// DataDir is an open data directory. Names passed to its methods are
+// resolved with [os.Root], so they cannot refer to anything outside the
+// directory, not even through ".." segments or symlinks.
+//
+// A DataDir is safe for concurrent use. Close it when done.
+type DataDir struct {
+	root *os.Root
+}
+
+// OpenDataDir opens the existing directory dir as a [DataDir].
+//
+// Returns an error matching [ErrDataDir] on failure.
+func OpenDataDir(dir string) (d *DataDir, err error) {
+	var root *os.Root
+	if root, err = os.OpenRoot(dir); err == nil {
+		d = &DataDir{root: root}
+	}
+	err = newErrDataDir(dir, err)
+	return
+}
+
+// OpenDataDir opens cfg.DataDir as a [DataDir], typically after
+// [Config.Listen] has resolved and created it.
+//
+// Returns an error matching [ErrDataDir] on failure, including when
+// cfg.DataDir is empty.
+func (cfg *Config) OpenDataDir() (d *DataDir, err error) {
+	if cfg.DataDir != "" {
+		d, err = OpenDataDir(cfg.DataDir)
+	} else {
+		err = newErrDataDir("", errNoDataDir)
+	}
+	return
+}
+
+// Close closes the directory. Files opened from it remain open.
+func (d *DataDir) Close() error {
+	return d.root.Close()
+}
+
+// Path returns the path the directory was opened with.
+func (d *DataDir) Path() string {
+	return d.root.Name()
+}
+
+// Root returns the underlying [os.Root] for operations not covered here.
+func (d *DataDir) Root() *os.Root {
+	return d.root
+}
+
+// Join returns the path of name inside the directory, for use with APIs that
+// need a path rather than an open file. It fails with an error matching
+// [ErrPathEscape] if name is not a local path (see [path/filepath.IsLocal]),
+// and with the error from [os.Root] if resolving it within the directory
+// would leave it through a symlink, even one whose target does not exist.
+// name and its parents need not exist.
+//
+// The check is made when Join is called; the directory must not be modified
+// by untrusted parties afterwards for the path to stay inside.
+func (d *DataDir) Join(name string) (p string, err error) {
+	err = PathEscapeError{Base: d.root.Name(), Path: name}
+	if filepath.IsLocal(name) {
+		// Missing trailing components cannot escape, so check the deepest
+		// ancestor that exists.
+		_, err = d.root.Stat(name)
+		for dir := name; errors.Is(err, fs.ErrNotExist) && dir != "."; {
+			dir = filepath.Dir(dir)
+			_, err = d.root.Stat(dir)
+		}
+		if err == nil {
+			p = filepath.Join(d.root.Name(), name)
+		} else {
+			err = &fs.PathError{Op: "join", Path: name, Err: err}
+		}
+	}
+	return
+}
+
+// Open opens name inside the directory for reading.
+func (d *DataDir) Open(name string) (*os.File, error) {
+	return d.root.Open(name)
+}
+
+// OpenFile opens name inside the directory with the given flags and
+// permissions, like [os.OpenFile].
+func (d *DataDir) OpenFile(name string, flag int, perm fs.FileMode) (*os.File, error) {
+	return d.root.OpenFile(name, flag, perm)
+}
+
+// ReadFile reads the named file inside the directory.
+func (d *DataDir) ReadFile(name string) ([]byte, error) {
+	return d.root.ReadFile(name)
+}
+
+// MkdirAll creates the directory name and any missing parents inside the
+// directory, like [os.MkdirAll].
+func (d *DataDir) MkdirAll(name string, perm fs.FileMode) error {
+	return d.root.MkdirAll(name, perm)
+}
+
+// Remove removes the named file or empty directory inside the directory.
+func (d *DataDir) Remove(name string) error {
+	return d.root.Remove(name)
+}
+
+// WriteFile atomically replaces the named file inside the directory with
+// data. The data is written to a temporary file in the same directory, which
+// is synced and then renamed over name, after which the containing directory
+// is synced too. Readers see either the old or the new content, and after a
+// crash the file holds one of them.
+//
+// As with [os.WriteFile], perm (before umask) is used when the file is
+// created; an existing file's permissions are not preserved.
+func (d *DataDir) WriteFile(name string, data []byte, perm fs.FileMode) (err error) {
+	dir, base := filepath.Split(name)
+	var tmp string
+	var f *os.File
+	for tries := 0; tries < 100; tries++ {
+		tmp = filepath.Join(dir, "."+base+".tmp-"+strconv.FormatUint(rand.Uint64(), 36))
+		if f, err = d.root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm); !errors.Is(err, fs.ErrExist) {
+			break
+		}
+	}
+	if err == nil {
+		if _, err = f.Write(data); err == nil {
+			err = f.Sync()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			if err = d.root.Rename(tmp, name); err == nil {
+				err = d.syncDir(dir)
+			}
+		}
+		if err != nil {
+			_ = d.root.Remove(tmp)
+		}
+	}
+	return
+}
+
+var syncDirFn = (*os.File).Sync
+
+// syncDir flushes the directory entry changes of dir to disk. Windows cannot
+// sync directories, and other systems that cannot are not treated as failing.
+func (d *DataDir) syncDir(dir string) (err error) {
+	if runtime.GOOS != "windows" {
+		if dir == "" {
+			dir = "."
+		}
+		var f *os.File
+		if f, err = d.root.Open(dir); err == nil {
+			if err = syncDirFn(f); isSyncDirUnsupported(err) {
+				err = nil
+			}
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}
+	return
+}
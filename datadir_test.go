@@ -0,0 +1,106 @@
+package webserv_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/linkdata/webserv"
+)
+
+func openTestDataDir(t *testing.T) (*webserv.DataDir, string) {
+	t.Helper()
+	dir := t.TempDir()
+	d, err := webserv.OpenDataDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+	return d, dir
+}
+
+func TestDataDir_WriteFileIsAtomicReplace(t *testing.T) {
+	d, dir := openTestDataDir(t)
+	if err := d.MkdirAll("state", 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"first", "second"} {
+		if err := d.WriteFile(filepath.Join("state", "file"), []byte(content), 0o640); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := d.ReadFile(filepath.Join("state", "file")); err != nil || string(got) != content {
+			t.Fatalf("ReadFile() = (%q, %v), want %q", got, err, content)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "file" {
+		t.Fatalf("state directory holds %v, want only the file", entries)
+	}
+	if d.Path() != dir {
+		t.Errorf("Path() = %q, want %q", d.Path(), dir)
+	}
+}
+
+func TestDataDir_RefusesToEscape(t *testing.T) {
+	d, dir := openTestDataDir(t)
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	canSymlink := os.Symlink(outside, filepath.Join(dir, "link")) == nil
+	if !canSymlink && runtime.GOOS != "windows" {
+		t.Fatal("symlink failed")
+	}
+
+	if p, err := d.Join(filepath.Join("sub", "file")); err != nil || p != filepath.Join(dir, "sub", "file") {
+		t.Errorf("Join(sub/file) = (%q, %v), want path inside", p, err)
+	}
+	names := []string{"..", filepath.Join("..", "x"), filepath.Join("a", "..", "..", "x"), outside}
+	if canSymlink {
+		names = append(names, filepath.Join("link", "secret"), filepath.Join("link", "new"))
+	}
+	for _, name := range names {
+		if p, err := d.Join(name); err == nil {
+			t.Errorf("Join(%q) = %q, want error", name, p)
+		}
+		if f, err := d.Open(name); err == nil {
+			_ = f.Close()
+			t.Errorf("Open(%q) succeeded", name)
+		}
+		if err := d.WriteFile(name, []byte("x"), 0o600); err == nil {
+			t.Errorf("WriteFile(%q) succeeded", name)
+		}
+	}
+	if canSymlink {
+		if err := os.Symlink(filepath.Join(outside, "victim"), filepath.Join(dir, "dangling")); err != nil {
+			t.Fatal(err)
+		}
+		if p, err := d.Join("dangling"); err == nil {
+			t.Errorf("Join(dangling) = %q, want error", p)
+		}
+	}
+}
+
+func TestConfigOpenDataDir(t *testing.T) {
+	if _, err := (&webserv.Config{}).OpenDataDir(); !errors.Is(err, webserv.ErrDataDir) {
+		t.Fatalf("OpenDataDir() without DataDir = %v, want match %v", err, webserv.ErrDataDir)
+	}
+	cfg := &webserv.Config{DataDir: filepath.Join(t.TempDir(), "missing")}
+	if _, err := cfg.OpenDataDir(); !errors.Is(err, webserv.ErrDataDir) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("OpenDataDir() = %v, want match %v and %v", err, webserv.ErrDataDir, fs.ErrNotExist)
+	}
+	cfg.DataDir = t.TempDir()
+	d, err := cfg.OpenDataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
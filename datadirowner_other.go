@@ -0,0 +1,16 @@
+//go:build !(unix || linux)
+
+package webserv
+
+import (
+	"errors"
+	"io/fs"
+)
+
+func checkOwner(fs.FileInfo) error {
+	return errors.ErrUnsupported
+}
+
+func (userIdentity) mkdirAllOwned(string, fs.FileMode) (bool, error) {
+	return false, errors.ErrUnsupported
+}
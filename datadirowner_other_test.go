@@ -0,0 +1,16 @@
+//go:build !(unix || linux)
+
+package webserv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestCheckDataDir_UnsupportedOS(t *testing.T) {
+	if err := webserv.CheckDataDir(t.TempDir(), 0); !errors.Is(err, webserv.ErrDataDir) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("error %v does not match webserv.ErrDataDir and errors.ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+var chownFn = os.Chown
+
+func checkOwner(fi fs.FileInfo) (err error) {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		if euid := os.Geteuid(); int(st.Uid) != euid {
+			err = fmt.Errorf("owned by uid %d, not the effective uid %d: %w", st.Uid, euid, fs.ErrPermission)
+		}
+	}
+	return
+}
+
+// mkdirAllOwned creates dir and any missing parents with mode and changes
+// the owner of every directory it created to the target user. It does
+// nothing unless running as root, leaving creation to [UseDataDir] after the
+// user switch. Returns true if dir was created.
+func (id userIdentity) mkdirAllOwned(dir string, mode fs.FileMode) (created bool, err error) {
+	if geteuidFn() == 0 {
+		var missing []string
+		p := dir
+		_, err = os.Lstat(p)
+		for errors.Is(err, fs.ErrNotExist) && p != filepath.Dir(p) {
+			missing = append(missing, p)
+			p = filepath.Dir(p)
+			_, err = os.Lstat(p)
+		}
+		if err == nil && len(missing) > 0 {
+			if err = os.MkdirAll(dir, mode); err == nil {
+				created = true
+				for i := len(missing) - 1; i >= 0 && err == nil; i-- {
+					err = chownFn(missing[i], id.uid, id.gid)
+				}
+			}
+		}
+	}
+	return
+}
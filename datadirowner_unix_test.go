@@ -0,0 +1,150 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckDataDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckDataDir(dir, defaultDataDirForbiddenPerm); err != nil {
+		t.Fatalf("CheckDataDir(0700) = %v, want nil", err)
+	}
+	if err := CheckDataDir("", defaultDataDirForbiddenPerm); err != nil {
+		t.Fatalf("CheckDataDir(\"\") = %v, want nil", err)
+	}
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	err := CheckDataDir(dir, defaultDataDirForbiddenPerm)
+	if !errors.Is(err, ErrDataDir) || !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("CheckDataDir(0777) = %v, want match %v and %v", err, ErrDataDir, fs.ErrPermission)
+	}
+	if !strings.Contains(err.Error(), "----w--w-") {
+		t.Errorf("CheckDataDir(0777) = %q, want forbidden bits named", err)
+	}
+	if err = CheckDataDir(dir, 0); err != nil {
+		t.Fatalf("CheckDataDir(0777, 0) = %v, want nil", err)
+	}
+	if err = CheckDataDir(file, 0); !errors.Is(err, ErrDataDir) || !errors.Is(err, errNotDirectory) {
+		t.Fatalf("CheckDataDir(file) = %v, want match %v and %v", err, ErrDataDir, errNotDirectory)
+	}
+	if err = CheckDataDir(filepath.Join(dir, "missing"), 0); !errors.Is(err, ErrDataDir) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("CheckDataDir(missing) = %v, want match %v and %v", err, ErrDataDir, fs.ErrNotExist)
+	}
+}
+
+func TestCheckDataDir_WrongOwnerFails(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root to chown")
+	}
+	dir := t.TempDir()
+	if err := os.Chown(dir, 65534, 65534); err != nil {
+		t.Fatal(err)
+	}
+	err := CheckDataDir(dir, 0)
+	if !errors.Is(err, ErrDataDir) || !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("CheckDataDir() = %v, want match %v and %v", err, ErrDataDir, fs.ErrPermission)
+	}
+	if !strings.Contains(err.Error(), "uid 65534") {
+		t.Errorf("CheckDataDir() = %q, want owner named", err)
+	}
+}
+
+func stubChown(t *testing.T, euid int) *[]string {
+	t.Helper()
+	savedChown, savedGeteuid := chownFn, geteuidFn
+	t.Cleanup(func() { chownFn, geteuidFn = savedChown, savedGeteuid })
+	var chowned []string
+	chownFn = func(name string, uid, gid int) error {
+		if uid != 1234 || gid != 5678 {
+			t.Errorf("chown(%q, %d, %d), want ids 1234:5678", name, uid, gid)
+		}
+		chowned = append(chowned, name)
+		return nil
+	}
+	geteuidFn = func() int { return euid }
+	return &chowned
+}
+
+func TestMkdirAllOwned_ChownsCreatedDirectories(t *testing.T) {
+	chowned := stubChown(t, 0)
+	base := t.TempDir()
+	dir := filepath.Join(base, "a", "b")
+	id := userIdentity{uid: 1234, gid: 5678}
+
+	created, err := id.mkdirAllOwned(dir, 0o750)
+	if err != nil || !created {
+		t.Fatalf("mkdirAllOwned() = (%v, %v), want (true, nil)", created, err)
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		t.Fatalf("Stat(%q) = %v, want directory", dir, err)
+	}
+	if want := []string{filepath.Join(base, "a"), dir}; !reflect.DeepEqual(*chowned, want) {
+		t.Fatalf("chowned = %v, want %v", *chowned, want)
+	}
+
+	*chowned = nil
+	if created, err = id.mkdirAllOwned(dir, 0o750); err != nil || created || len(*chowned) != 0 {
+		t.Fatalf("mkdirAllOwned(existing) = (%v, %v) chowning %v, want (false, nil) and no chown", created, err, *chowned)
+	}
+}
+
+func TestMkdirAllOwned_NotRootDoesNothing(t *testing.T) {
+	chowned := stubChown(t, 1000)
+	dir := filepath.Join(t.TempDir(), "a")
+	created, err := userIdentity{uid: 1234, gid: 5678}.mkdirAllOwned(dir, 0o750)
+	if err != nil || created || len(*chowned) != 0 {
+		t.Fatalf("mkdirAllOwned() = (%v, %v) chowning %v, want (false, nil) and no chown", created, err, *chowned)
+	}
+	if _, err = os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Stat(%q) = %v, want %v", dir, err, fs.ErrNotExist)
+	}
+}
+
+func TestConfigListen_DataDirCheckRejectsWorldWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Address: "127.0.0.1:0", DataDir: dir, DataDirCheck: true}
+	l, err := cfg.Listen()
+	if l != nil {
+		_ = l.Close()
+		t.Fatal("Listen() returned a listener")
+	}
+	if !errors.Is(err, ErrDataDir) || !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("Listen() = %v, want match %v and %v", err, ErrDataDir, fs.ErrPermission)
+	}
+
+	cfg = &Config{Address: "127.0.0.1:0", DataDir: dir, DataDirCheck: true, DataDirForbiddenPerm: 0o400}
+	if l, err = cfg.Listen(); err == nil {
+		_ = l.Close()
+	}
+	if !errors.Is(err, ErrDataDir) {
+		t.Fatalf("Listen() with 0o400 forbidden = %v, want match %v", err, ErrDataDir)
+	}
+
+	if err = os.Chmod(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	cfg = &Config{Address: "127.0.0.1:0", DataDir: dir, DataDirCheck: true}
+	if l, err = cfg.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Close()
+}
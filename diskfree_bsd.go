@@ -0,0 +1,11 @@
+//go:build darwin || freebsd || dragonfly
+
+package webserv
+
+import "syscall"
+
+// statfsBlockSize returns the unit of the block counts in st, which on the
+// BSDs is Bsize, the fundamental block size.
+func statfsBlockSize(st *syscall.Statfs_t) uint64 {
+	return nonNegative(st.Bsize)
+}
@@ -0,0 +1,9 @@
+package webserv
+
+import "syscall"
+
+// statfsBlockSize returns the unit of the block counts in st. On Linux that
+// is the fragment size; Bsize is the preferred I/O size, which may differ.
+func statfsBlockSize(st *syscall.Statfs_t) uint64 {
+	return nonNegative(st.Frsize)
+}
@@ -0,0 +1,25 @@
+package webserv
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestDiskFree_UsesFragmentSize(t *testing.T) {
+	dir := t.TempDir()
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		t.Fatal(err)
+	}
+	freeBytes, _, err := diskFree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Other writers may change the count in between, so compare the units.
+	if st.Frsize > 0 && freeBytes%uint64(st.Frsize) != 0 {
+		t.Fatalf("diskFree() = %d bytes, not a multiple of the fragment size %d", freeBytes, st.Frsize)
+	}
+	if got := statfsBlockSize(&st); got != uint64(st.Frsize) {
+		t.Fatalf("statfsBlockSize() = %d, want Frsize %d", got, st.Frsize)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || dragonfly)
+
+package webserv
+
+import "errors"
+
+func diskFree(string) (uint64, uint64, error) {
+	return 0, 0, errors.ErrUnsupported
+}
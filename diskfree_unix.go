@@ -0,0 +1,36 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+package webserv
+
+import (
+	"os"
+	"syscall"
+)
+
+// statfsValue abstracts over the signed and unsigned field types of
+// [syscall.Statfs_t] on the supported systems.
+type statfsValue interface {
+	~int32 | ~int64 | ~uint32 | ~uint64
+}
+
+func nonNegative[T statfsValue](v T) uint64 {
+	if v < 0 {
+		return 0
+	}
+	return uint64(v)
+}
+
+// diskFree returns the space available to an unprivileged user on the
+// filesystem holding path, and its number of free inodes. statfs has no
+// unprivileged inode count, so freeInodes includes any inodes the filesystem
+// reserves for root.
+func diskFree(path string) (freeBytes, freeInodes uint64, err error) {
+	var st syscall.Statfs_t
+	if err = syscall.Statfs(path, &st); err == nil {
+		freeBytes = nonNegative(st.Bavail) * statfsBlockSize(&st)
+		freeInodes = nonNegative(st.Ffree)
+	} else {
+		err = os.NewSyscallError("statfs", err)
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package webserv
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+)
+
+// dumpFileMode is the mode of dump files written to the data directory; they
+// may contain request data found on goroutine stacks.
+const dumpFileMode = 0o600
+
+// dumpTimeFormat is used in dump file names so they sort chronologically.
+const dumpTimeFormat = "20060102T150405.000Z"
+
+// WriteDump writes the stacks of all goroutines followed by a heap profile to
+// w, both in the text format of [runtime/pprof].
+func WriteDump(w io.Writer) (err error) {
+	if err = pprof.Lookup("goroutine").WriteTo(w, 2); err == nil {
+		if _, err = io.WriteString(w, "\n"); err == nil {
+			err = pprof.Lookup("heap").WriteTo(w, 1)
+		}
+	}
+	return
+}
+
+// Dump writes diagnostics for a hung or misbehaving server without
+// disturbing it: the stacks of all goroutines and a heap profile, as by
+// [WriteDump].
+//
+// If cfg.DataDir is set, the dump is written atomically to a file named
+// "dump-<UTC timestamp>.txt" there, readable only by the owner, and the file
+// path is returned and logged. Otherwise the dump is logged as a whole
+// through cfg.Logger, and name is empty.
+//
+// [Config.ServeWith] calls Dump on [Config.DumpSignal]. Dump may also be
+// called directly, concurrently with serving.
+func (cfg *Config) Dump() (name string, err error) {
+	var buf bytes.Buffer
+	if err = WriteDump(&buf); err == nil {
+		if cfg.DataDir != "" {
+			var dd *DataDir
+			if dd, err = cfg.OpenDataDir(); err == nil {
+				fileName := "dump-" + time.Now().UTC().Format(dumpTimeFormat) + ".txt"
+				if err = dd.WriteFile(fileName, buf.Bytes(), dumpFileMode); err == nil {
+					name = filepath.Join(dd.Path(), fileName)
+				}
+				_ = dd.Close()
+			}
+			if err == nil {
+				cfg.logInfo("dump written", "file", name)
+			}
+		} else {
+			cfg.logInfo("dump", "dump", buf.String())
+		}
+	}
+	if err != nil {
+		cfg.logError("dump failed", "err", err)
+	}
+	return
+}
@@ -0,0 +1,71 @@
+package webserv_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestWriteDump_IncludesGoroutinesAndHeap(t *testing.T) {
+	var buf bytes.Buffer
+	if err := webserv.WriteDump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"goroutine ", "TestWriteDump_IncludesGoroutinesAndHeap", "heap profile: "} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("dump lacks %q", want)
+		}
+	}
+}
+
+func TestConfigDump_WritesFileInDataDir(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &webserv.Config{DataDir: dir}
+	name, err := cfg.Dump()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(name) != dir || !strings.HasPrefix(filepath.Base(name), "dump-") {
+		t.Fatalf("Dump() = %q, want dump file in %q", name, dir)
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" {
+		if perm := fi.Mode().Perm(); perm != 0o600 {
+			t.Errorf("dump file mode = %v, want %v", perm, os.FileMode(0o600))
+		}
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "heap profile: ") {
+		t.Fatal("dump file lacks heap profile")
+	}
+}
+
+func TestConfigDump_LogsWithoutDataDir(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &webserv.Config{Logger: newNotifyingLogger(&buf, "")}
+	name, err := cfg.Dump()
+	if err != nil || name != "" {
+		t.Fatalf("Dump() = (%q, %v), want (\"\", nil)", name, err)
+	}
+	if !strings.Contains(buf.String(), "webserv: dump") || !strings.Contains(buf.String(), "heap profile: ") {
+		t.Fatalf("dump not logged: %q", buf.String())
+	}
+}
+
+func TestConfigDump_MissingDataDirFails(t *testing.T) {
+	cfg := &webserv.Config{DataDir: filepath.Join(t.TempDir(), "missing")}
+	if _, err := cfg.Dump(); err == nil {
+		t.Fatal("Dump() = nil error for missing data directory")
+	}
+}
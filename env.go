@@ -0,0 +1,113 @@
+package webserv
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+)
+
+// configVar is a Config field that can be set from text.
+type configVar struct {
+	name  string     // lower case name; environment variables use it in upper case
+	value flag.Value // sets and formats the field
+	usage string     // help text for the command-line flag
+}
+
+// vars returns the fields of cfg that can be set from text, in declaration
+// order. Logger and the callbacks cannot.
+func (cfg *Config) vars() []configVar {
+	return []configVar{
+		{"address", stringValue{&cfg.Address}, "serve HTTP requests on given [address][:port]"},
+		{"certdir", stringValue{&cfg.CertDir}, "where to find fullchain.pem and privkey.pem"},
+		{"fullchainpem", stringValue{&cfg.FullchainPem}, "certificate chain file name in certdir, instead of fullchain.pem"},
+		{"privkeypem", stringValue{&cfg.PrivkeyPem}, "private key file name in certdir, instead of privkey.pem"},
+		{"strictpaths", boolValue{&cfg.StrictPaths}, "require certificate files and data dir suffix to stay inside their directories"},
+		{"user", stringValue{&cfg.User}, "switch to this user after startup, as user[:group] or uid[:gid] (*nix only)"},
+		{"groups", listValue{&cfg.Groups}, "comma-separated supplementary groups to use after switching user"},
+		{"dropcapabilities", boolValue{&cfg.DropCapabilities}, "drop all capabilities and set no_new_privs after startup (Linux only)"},
+		{"chroot", stringValue{&cfg.Chroot}, "chroot into this directory after startup (*nix only)"},
+		{"landlock", boolValue{&cfg.Landlock}, "restrict filesystem access with Landlock after startup (Linux only)"},
+		{"landlockreadpaths", listValue{&cfg.LandlockReadPaths}, "comma-separated extra paths Landlock allows reading"},
+		{"landlockwritepaths", listValue{&cfg.LandlockWritePaths}, "comma-separated extra paths Landlock allows writing"},
+		{"datadir", stringValue{&cfg.DataDir}, "where to store data files after startup"},
+		{"defaultdatadirsuffix", stringValue{&cfg.DefaultDataDirSuffix}, "suffix for the default directories when datadir is not given"},
+		{"datadirmode", modeValue{&cfg.DataDirMode}, "octal mode to create datadir with if it does not exist"},
+		{"datadirxdg", boolValue{&cfg.DataDirXDG}, "default datadir under XDG_DATA_HOME"},
+		{"configdir", stringValue{&cfg.ConfigDir}, "configuration directory"},
+		{"configdirmode", modeValue{&cfg.ConfigDirMode}, "octal mode to create configdir with if it does not exist"},
+		{"statedir", stringValue{&cfg.StateDir}, "state directory"},
+		{"statedirmode", modeValue{&cfg.StateDirMode}, "octal mode to create statedir with if it does not exist"},
+		{"cachedir", stringValue{&cfg.CacheDir}, "cache directory"},
+		{"cachedirmode", modeValue{&cfg.CacheDirMode}, "octal mode to create cachedir with if it does not exist"},
+		{"runtimedir", stringValue{&cfg.RuntimeDir}, "runtime directory"},
+		{"runtimedirmode", modeValue{&cfg.RuntimeDirMode}, "octal mode to create runtimedir with if it does not exist"},
+		{"datadircheck", boolValue{&cfg.DataDirCheck}, "require datadir to be owned by us and not writable by others"},
+		{"datadirforbiddenperm", modeValue{&cfg.DataDirForbiddenPerm}, "octal permission bits datadir must not have with datadircheck; zero means 022"},
+		{"datadirchown", boolValue{&cfg.DataDirChown}, "create datadir as root and give it to the user"},
+		{"datadirprobe", boolValue{&cfg.DataDirProbe}, "verify that datadir is writable after startup"},
+		{"datadirminfreebytes", uint64Value{&cfg.DataDirMinFreeBytes}, "minimum free bytes on the datadir filesystem"},
+		{"datadirminfreeinodes", uint64Value{&cfg.DataDirMinFreeInodes}, "minimum free inodes on the datadir filesystem"},
+		{"datadirmonitor", durationValue{&cfg.DataDirMonitor}, "interval to repeat the datadir checks at while serving"},
+		{"lockfile", stringValue{&cfg.LockFile}, "name of a lock file in datadir that prevents running twice"},
+		{"pidfile", stringValue{&cfg.PIDFile}, "name of a file in datadir to write the process ID to"},
+		{"listenurl", stringValue{&cfg.ListenURL}, "specify the external URL clients can reach us at"},
+		{"umask", modeValue{&cfg.Umask}, "octal process umask to set at startup (*nix only)"},
+		{"maxopenfiles", uint64Value{&cfg.MaxOpenFiles}, "open file limit to set at startup (*nix only)"},
+		{"disablecoredumps", boolValue{&cfg.DisableCoreDumps}, "disable core dumps at startup (*nix only)"},
+		{"draindelay", durationValue{&cfg.DrainDelay}, "time to keep serving after a shutdown is requested"},
+		{"shutdowntimelimit", durationValue{&cfg.ShutdownTimeLimit}, "maximum time to wait for a graceful shutdown; zero means 1s"},
+		{"dumpsignal", signalValue{&cfg.DumpSignal}, "signal that writes goroutine and heap dumps, such as SIGUSR1"},
+		{"disablesignals", boolValue{&cfg.DisableSignals}, "handle no signals at all"},
+		{"logtlserrors", boolValue{&cfg.LogTLSErrors}, "log TLS handshake errors"},
+		{"signals", signalsValue{&cfg.Signals}, "comma-separated signal=action pairs replacing the default signal handling, such as SIGTERM=shutdown,SIGHUP=reload"},
+	}
+}
+
+// envName returns the environment variable name for the Config field name.
+func envName(prefix, name string) string {
+	name = strings.ToUpper(name)
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	return name
+}
+
+// loadEnv sets v from its environment variable for prefix, if that is set
+// and not empty.
+func (v configVar) loadEnv(prefix string) (err error) {
+	name := envName(prefix, v.name)
+	if s := os.Getenv(name); s != "" {
+		err = newErrEnv(name, s, v.value.Set(s))
+	}
+	return
+}
+
+// LoadEnv sets the fields of cfg from the environment variables named after
+// them in upper case, prefixed with prefix and an underscore, such as
+// WEBSERV_ADDRESS, WEBSERV_CERTDIR or WEBSERV_SHUTDOWNTIMELIMIT for the prefix
+// "WEBSERV". With an empty prefix the names are used as is. Fields whose
+// variable is unset or empty are left alone. Logger and the callbacks cannot
+// be set this way.
+//
+// Values are parsed according to the field type:
+//   - booleans with [strconv.ParseBool], such as "true" or "1";
+//   - durations with [time.ParseDuration], such as "5s";
+//   - modes as octal numbers, such as "0750" or "022";
+//   - sizes and counts as decimal numbers;
+//   - lists, such as Groups, as comma-separated values;
+//   - DumpSignal as a signal name, such as "SIGUSR1";
+//   - Signals as comma-separated signal=action pairs, such as
+//     "SIGQUIT=shutdown,SIGINT=ignore", with actions named as by
+//     [SignalAction.String].
+//
+// All variables are processed. The returned error joins an error matching
+// [ErrEnv] for each variable that failed to parse; those fields are left
+// unchanged.
+func (cfg *Config) LoadEnv(prefix string) (err error) {
+	var errs []error
+	for _, v := range cfg.vars() {
+		errs = append(errs, v.loadEnv(prefix))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+package webserv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestConfigVars_CoverSettableFields guards against new Config fields being
+// forgotten by LoadEnv.
+func TestConfigVars_CoverSettableFields(t *testing.T) {
+	cfg := &Config{}
+	names := make(map[string]bool)
+	for _, v := range cfg.vars() {
+		names[v.name] = true
+	}
+	typ := reflect.TypeFor[Config]()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if field.IsExported() && field.Name != "Logger" && field.Type.Kind() != reflect.Func {
+			name := strings.ToLower(field.Name)
+			if !names[name] {
+				t.Errorf("Config.%s has no variable %q", field.Name, name)
+			}
+			delete(names, name)
+		}
+	}
+	for name := range names {
+		t.Errorf("variable %q has no Config field", name)
+	}
+}
+
+func TestConfigVars_StringRoundTrips(t *testing.T) {
+	src := &Config{}
+	for _, v := range src.vars() {
+		var s string
+		switch v.value.(type) {
+		case boolValue:
+			s = "true"
+		case modeValue:
+			s = "0750"
+		case uint64Value:
+			s = "42"
+		case durationValue:
+			s = "1m30s"
+		case listValue:
+			s = "a,b"
+		case signalValue:
+			s = "SIGTERM"
+		case signalsValue:
+			s = "SIGINT=dump,SIGTERM=shutdown"
+		default:
+			s = "text"
+		}
+		if err := v.value.Set(s); err != nil {
+			t.Fatalf("%s.Set(%q) = %v", v.name, s, err)
+		}
+		if got := v.value.String(); got != s {
+			t.Errorf("%s.String() = %q after Set(%q)", v.name, got, s)
+		}
+	}
+	dst := &Config{}
+	srcVars := src.vars()
+	for i, v := range dst.vars() {
+		if err := v.value.Set(srcVars[i].value.String()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("Config does not round trip:\n%+v\n%+v", src, dst)
+	}
+}
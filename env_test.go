@@ -0,0 +1,90 @@
+package webserv_test
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestConfigLoadEnv_ParsesFields(t *testing.T) {
+	t.Setenv("WEBSERV_ADDRESS", "127.0.0.1:8080")
+	t.Setenv("WEBSERV_CERTDIR", "/etc/certs")
+	t.Setenv("WEBSERV_STRICTPATHS", "true")
+	t.Setenv("WEBSERV_GROUPS", "ssl-cert, www-data")
+	t.Setenv("WEBSERV_DATADIRMODE", "0750")
+	t.Setenv("WEBSERV_UMASK", "027")
+	t.Setenv("WEBSERV_DATADIRMINFREEBYTES", "1048576")
+	t.Setenv("WEBSERV_SHUTDOWNTIMELIMIT", "5s")
+	t.Setenv("WEBSERV_SIGNALS", "SIGTERM=shutdown,int=ignore")
+	t.Setenv("WEBSERV_USER", "")
+
+	cfg := &webserv.Config{User: "keep", DrainDelay: time.Second}
+	if err := cfg.LoadEnv("WEBSERV"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Address != "127.0.0.1:8080" || cfg.CertDir != "/etc/certs" || !cfg.StrictPaths {
+		t.Errorf("string and bool fields = %q, %q, %v", cfg.Address, cfg.CertDir, cfg.StrictPaths)
+	}
+	if want := []string{"ssl-cert", "www-data"}; !slices.Equal(cfg.Groups, want) {
+		t.Errorf("Groups = %q, want %q", cfg.Groups, want)
+	}
+	if cfg.DataDirMode != 0o750 || cfg.Umask != 0o027 {
+		t.Errorf("modes = %#o, %#o, want 0750, 027", cfg.DataDirMode, cfg.Umask)
+	}
+	if cfg.DataDirMinFreeBytes != 1<<20 || cfg.ShutdownTimeLimit != 5*time.Second {
+		t.Errorf("DataDirMinFreeBytes = %d, ShutdownTimeLimit = %v", cfg.DataDirMinFreeBytes, cfg.ShutdownTimeLimit)
+	}
+	if len(cfg.Signals) != 2 || cfg.Signals[syscall.SIGTERM] != webserv.SignalShutdown || cfg.Signals[os.Interrupt] != webserv.SignalIgnore {
+		t.Errorf("Signals = %v", cfg.Signals)
+	}
+	if cfg.User != "keep" || cfg.DrainDelay != time.Second {
+		t.Errorf("unset fields changed: User = %q, DrainDelay = %v", cfg.User, cfg.DrainDelay)
+	}
+}
+
+func TestConfigLoadEnv_ReportsEachParseError(t *testing.T) {
+	t.Setenv("APP_LANDLOCK", "maybe")
+	t.Setenv("APP_DATADIRMODE", "0999")
+	t.Setenv("APP_STATEDIRMODE", "01777")
+	t.Setenv("APP_DRAINDELAY", "soon")
+	t.Setenv("APP_DUMPSIGNAL", "SIGNOPE")
+	t.Setenv("APP_SIGNALS", "SIGTERM=explode")
+	t.Setenv("APP_ADDRESS", ":8443")
+
+	cfg := &webserv.Config{DataDirMode: 0o700, DrainDelay: time.Second}
+	err := cfg.LoadEnv("APP")
+	if !errors.Is(err, webserv.ErrEnv) {
+		t.Fatalf("LoadEnv() = %v, want match %v", err, webserv.ErrEnv)
+	}
+	var names []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var envErr webserv.EnvError
+		if !errors.As(e, &envErr) {
+			t.Fatalf("LoadEnv() error %v is not an EnvError", e)
+		}
+		names = append(names, envErr.Name)
+	}
+	want := []string{"APP_LANDLOCK", "APP_DATADIRMODE", "APP_STATEDIRMODE", "APP_DRAINDELAY", "APP_DUMPSIGNAL", "APP_SIGNALS"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("LoadEnv() failed for %q, want %q", names, want)
+	}
+	if cfg.DataDirMode != 0o700 || cfg.DrainDelay != time.Second || cfg.Landlock || cfg.Signals != nil {
+		t.Fatalf("fields that failed to parse changed: %#o, %v, %v, %v", cfg.DataDirMode, cfg.DrainDelay, cfg.Landlock, cfg.Signals)
+	}
+	if cfg.Address != ":8443" {
+		t.Fatalf("Address = %q, want variables after a failure still applied", cfg.Address)
+	}
+}
+
+func TestConfigLoadEnv_EmptyPrefix(t *testing.T) {
+	t.Setenv("LISTENURL", "https://example.test")
+	cfg := &webserv.Config{}
+	if err := cfg.LoadEnv(""); err != nil || cfg.ListenURL != "https://example.test" {
+		t.Fatalf("LoadEnv(\"\") = %v, ListenURL = %q", err, cfg.ListenURL)
+	}
+}
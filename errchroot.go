@@ -0,0 +1,36 @@
+package webserv
+
+import "fmt"
+
+// ChrootError is the error type returned by [Config.Listen] when confining the
+// process to [Config.Chroot] fails.
+//
+// Use [errors.As] to inspect the directory, or errors.Is(err, [ErrChroot]) to
+// test for the stage alone.
+type ChrootError struct {
+	Dir string // chroot directory, absolute if it could be resolved
+	Err error  // underlying cause
+}
+
+// ErrChroot matches errors returned by [Config.Listen] when the chroot fails.
+var ErrChroot = ChrootError{}
+
+func (e ChrootError) Error() string {
+	return fmt.Sprintf("Chroot(%q): %v", e.Dir, e.Err)
+}
+
+func (e ChrootError) Is(other error) (yes bool) {
+	_, yes = other.(ChrootError)
+	return
+}
+
+func (e ChrootError) Unwrap() error {
+	return e.Err
+}
+
+func newErrChroot(dir string, err error) error {
+	if err != nil {
+		err = ChrootError{Dir: dir, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,36 @@
+package webserv
+
+import "fmt"
+
+// DataDirError is the error type returned by [DefaultDataDir] and [UseDataDir]
+// when the data directory cannot be resolved or created.
+//
+// Use [errors.As] to inspect the path, or errors.Is(err, [ErrDataDir]) to test
+// for the stage alone.
+type DataDirError struct {
+	Path string // data directory as given, or as resolved so far
+	Err  error  // underlying cause
+}
+
+// ErrDataDir matches errors returned by [DefaultDataDir] and [UseDataDir] on failure.
+var ErrDataDir = DataDirError{}
+
+func (e DataDirError) Error() string {
+	return fmt.Sprintf("DataDir(%q): %v", e.Path, e.Err)
+}
+
+func (e DataDirError) Is(other error) (yes bool) {
+	_, yes = other.(DataDirError)
+	return
+}
+
+func (e DataDirError) Unwrap() error {
+	return e.Err
+}
+
+func newErrDataDir(path string, err error) error {
+	if err != nil {
+		err = DataDirError{Path: path, Err: err}
+	}
+	return err
+}
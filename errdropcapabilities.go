@@ -0,0 +1,30 @@
+package webserv
+
+import "fmt"
+
+type errDropCapabilities struct {
+	err error
+}
+
+// ErrDropCapabilities matches errors returned by [DropCapabilities] on failure.
+var ErrDropCapabilities = errDropCapabilities{}
+
+func (e errDropCapabilities) Error() string {
+	return fmt.Sprintf("DropCapabilities(): %v", e.err)
+}
+
+func (e errDropCapabilities) Is(other error) (yes bool) {
+	_, yes = other.(errDropCapabilities)
+	return
+}
+
+func (e errDropCapabilities) Unwrap() error {
+	return e.err
+}
+
+func newErrDropCapabilities(err error) error {
+	if err != nil {
+		err = errDropCapabilities{err: err}
+	}
+	return err
+}
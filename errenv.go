@@ -0,0 +1,38 @@
+package webserv
+
+import "fmt"
+
+// EnvError is the error type returned by [Config.LoadEnv] when an environment
+// variable cannot be parsed.
+//
+// Use [errors.As] to inspect the variable, or errors.Is(err, [ErrEnv]) to test
+// for the kind of error alone.
+type EnvError struct {
+	Name  string // environment variable name
+	Value string // value that failed to parse
+	Err   error  // underlying cause
+}
+
+// ErrEnv matches errors returned by [Config.LoadEnv] when an environment
+// variable cannot be parsed.
+var ErrEnv = EnvError{}
+
+func (e EnvError) Error() string {
+	return fmt.Sprintf("LoadEnv(%s=%q): %v", e.Name, e.Value, e.Err)
+}
+
+func (e EnvError) Is(other error) (yes bool) {
+	_, yes = other.(EnvError)
+	return
+}
+
+func (e EnvError) Unwrap() error {
+	return e.Err
+}
+
+func newErrEnv(name, value string, err error) error {
+	if err != nil {
+		err = EnvError{Name: name, Value: value, Err: err}
+	}
+	return err
+}
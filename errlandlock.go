@@ -0,0 +1,30 @@
+package webserv
+
+import "fmt"
+
+type errLandlock struct {
+	err error
+}
+
+// ErrLandlock matches errors returned by [Landlock] on failure.
+var ErrLandlock = errLandlock{}
+
+func (e errLandlock) Error() string {
+	return fmt.Sprintf("Landlock(): %v", e.err)
+}
+
+func (e errLandlock) Is(other error) (yes bool) {
+	_, yes = other.(errLandlock)
+	return
+}
+
+func (e errLandlock) Unwrap() error {
+	return e.err
+}
+
+func newErrLandlock(err error) error {
+	if err != nil {
+		err = errLandlock{err: err}
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package webserv
+
+import "fmt"
+
+// ListenError is the error type returned by [Listener] when the listen address
+// is invalid or the socket could not be opened.
+//
+// Use [errors.As] to inspect the address, or errors.Is(err, [ErrListen]) to
+// test for the stage alone.
+type ListenError struct {
+	Address string // address passed to net.Listen, or the invalid input address
+	Hint    string // if not empty, a suggestion for the operator, such as which process holds the port
+	Err     error  // underlying cause
+}
+
+// ErrListen matches errors returned by [Listener] when opening the socket fails.
+var ErrListen = ListenError{}
+
+func (e ListenError) Error() (s string) {
+	s = fmt.Sprintf("Listen(%q): %v", e.Address, e.Err)
+	if e.Hint != "" {
+		s += " (" + e.Hint + ")"
+	}
+	return
+}
+
+func (e ListenError) Is(other error) (yes bool) {
+	_, yes = other.(ListenError)
+	return
+}
+
+func (e ListenError) Unwrap() error {
+	return e.Err
+}
+
+func newErrListen(address string, err error) error {
+	if err != nil {
+		err = ListenError{Address: address, Hint: listenHint(address, err), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package webserv
+
+import "fmt"
+
+// LoadCertError is the error type returned by [LoadCert] when a non-empty
+// certificate directory was given but the key pair could not be loaded.
+//
+// Use [errors.As] to inspect the inputs, or errors.Is(err, [ErrLoadCert]) to
+// test for the stage alone.
+type LoadCertError struct {
+	CertDir      string // certificate directory, absolute if it could be resolved
+	FullchainPem string // certificate chain filename relative to CertDir
+	PrivkeyPem   string // private key filename relative to CertDir
+	Hint         string // if not empty, a suggestion for the operator, such as a missing file or mismatched key
+	Err          error  // underlying cause
+}
+
+// ErrLoadCert matches errors returned by [LoadCert] on failure.
+var ErrLoadCert = LoadCertError{}
+
+func (e LoadCertError) Error() (s string) {
+	s = fmt.Sprintf("LoadCert(%q, %q, %q): %v", e.CertDir, e.FullchainPem, e.PrivkeyPem, e.Err)
+	if e.Hint != "" {
+		s += " (" + e.Hint + ")"
+	}
+	return
+}
+
+func (e LoadCertError) Is(other error) (yes bool) {
+	_, yes = other.(LoadCertError)
+	return
+}
+
+func (e LoadCertError) Unwrap() error {
+	return e.Err
+}
+
+func newErrLoadCert(certDir, fullchainPem, privkeyPem string, err error) error {
+	if err != nil {
+		err = LoadCertError{CertDir: certDir, FullchainPem: fullchainPem, PrivkeyPem: privkeyPem, Hint: loadCertHint(err), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package webserv
+
+import "fmt"
+
+// LockError is the error type returned by [AcquireLock] and [Config.Listen]
+// when the single-instance lock file cannot be locked.
+//
+// Use [errors.As] to inspect the path and the process holding the lock, or
+// errors.Is(err, [ErrLock]) to test for the stage alone.
+type LockError struct {
+	Path string // lock file path
+	PID  int    // process ID of the holder if another instance holds the lock and it is known, else zero
+	Err  error  // underlying cause
+}
+
+// ErrLock matches errors returned by [AcquireLock] and [Config.Listen] when
+// the lock file cannot be locked.
+var ErrLock = LockError{}
+
+func (e LockError) Error() string {
+	if e.PID != 0 {
+		return fmt.Sprintf("Lock(%q): %v by pid %d", e.Path, e.Err, e.PID)
+	}
+	return fmt.Sprintf("Lock(%q): %v", e.Path, e.Err)
+}
+
+func (e LockError) Is(other error) (yes bool) {
+	_, yes = other.(LockError)
+	return
+}
+
+func (e LockError) Unwrap() error {
+	return e.Err
+}
+
+func newErrLock(path string, pid int, err error) error {
+	if err != nil {
+		err = LockError{Path: path, PID: pid, Err: err}
+	}
+	return err
+}
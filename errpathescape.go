@@ -0,0 +1,27 @@
+package webserv
+
+import "fmt"
+
+// PathEscapeError is the error type returned when a path that must stay
+// inside a base directory is not local to it or resolves outside of it
+// through symlinks. See [ConfinedPath].
+//
+// Use [errors.As] to inspect the paths, or errors.Is(err, [ErrPathEscape]) to
+// test for the condition alone.
+type PathEscapeError struct {
+	Base string // base directory the path must stay inside
+	Path string // offending path, relative to Base
+}
+
+// ErrPathEscape matches errors returned when a confined path escapes its base
+// directory.
+var ErrPathEscape = PathEscapeError{}
+
+func (e PathEscapeError) Error() string {
+	return fmt.Sprintf("PathEscape(%q, %q): path escapes from base directory", e.Base, e.Path)
+}
+
+func (e PathEscapeError) Is(other error) (yes bool) {
+	_, yes = other.(PathEscapeError)
+	return
+}
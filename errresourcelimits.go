@@ -0,0 +1,31 @@
+package webserv
+
+import "fmt"
+
+type errResourceLimits struct {
+	err error
+}
+
+// ErrResourceLimits matches errors returned by [Config.Listen] when the
+// configured umask or resource limits cannot be applied.
+var ErrResourceLimits = errResourceLimits{}
+
+func (e errResourceLimits) Error() string {
+	return fmt.Sprintf("ResourceLimits(): %v", e.err)
+}
+
+func (e errResourceLimits) Is(other error) (yes bool) {
+	_, yes = other.(errResourceLimits)
+	return
+}
+
+func (e errResourceLimits) Unwrap() error {
+	return e.err
+}
+
+func newErrResourceLimits(err error) error {
+	if err != nil {
+		err = errResourceLimits{err: err}
+	}
+	return err
+}
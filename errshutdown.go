@@ -0,0 +1,40 @@
+package webserv
+
+import "fmt"
+
+// ShutdownError is the error type returned by [Config.ServeWith] when the
+// graceful shutdown did not complete within [Config.ShutdownTimeLimit] and the
+// connections still open were closed.
+//
+// Use [errors.As] to inspect how many connections were closed, or
+// errors.Is(err, [ErrShutdown]) to test for the forced shutdown alone. The
+// error also matches its cause, usually [context.DeadlineExceeded].
+type ShutdownError struct {
+	Closed   int   // number of connections closed, including hijacked ones
+	Hijacked int   // number of closed connections registered with [Config.TrackHijacked]
+	Err      error // underlying cause
+}
+
+// ErrShutdown matches errors returned by [Config.ServeWith] when connections
+// had to be closed because the graceful shutdown timed out.
+var ErrShutdown = ShutdownError{}
+
+func (e ShutdownError) Error() string {
+	return fmt.Sprintf("Shutdown(): closed %d connections (%d hijacked): %v", e.Closed, e.Hijacked, e.Err)
+}
+
+func (e ShutdownError) Is(other error) (yes bool) {
+	_, yes = other.(ShutdownError)
+	return
+}
+
+func (e ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+func newErrShutdown(closed, hijacked int, err error) error {
+	if err != nil {
+		err = ShutdownError{Closed: closed, Hijacked: hijacked, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+package webserv
+
+import "flag"
+
+// RegisterFlags defines a flag on fs for every field of cfg that [Config.LoadEnv]
+// can set, named after the field in lower case, such as -address, -certdir,
+// -datadirmode or -shutdowntimelimit. Flag values are parsed the same way as
+// by LoadEnv.
+//
+// The default of each flag is the current value of its field, replaced by the
+// environment variable LoadEnv would use for prefix if that is set, so the
+// precedence is command line, then environment, then whatever cfg held
+// before. The help text names the variable. Environment variables that fail
+// to parse are logged as warnings and otherwise ignored; call LoadEnv first to
+// treat them as errors.
+//
+// Flags are bound to cfg, so they take effect when fs is parsed:
+//
+//	cfg := webserv.Config{User: "www-data", Logger: slog.Default()}
+//	cfg.RegisterFlags(flag.CommandLine, "WEBSERV")
+//	flag.Parse()
+//
+// Like [flag.FlagSet.Var], panics if a flag with the same name is already
+// defined on fs.
+func (cfg *Config) RegisterFlags(fs *flag.FlagSet, prefix string) {
+	for _, v := range cfg.vars() {
+		if err := v.loadEnv(prefix); err != nil {
+			cfg.logWarn("environment variable ignored", "err", err)
+		}
+		fs.Var(v.value, v.name, v.usage+" ($"+envName(prefix, v.name)+")")
+	}
+}
@@ -0,0 +1,88 @@
+package webserv_test
+
+import (
+	"bytes"
+	"flag"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestConfigRegisterFlags_Precedence(t *testing.T) {
+	t.Setenv("APP_USER", "env-user")
+	t.Setenv("APP_DATADIR", "/srv/env")
+	t.Setenv("APP_SHUTDOWNTIMELIMIT", "7s")
+
+	cfg := &webserv.Config{User: "www-data", CertDir: "/etc/certs", DataDirMode: 0o700}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.RegisterFlags(fs, "APP")
+	if cfg.User != "env-user" || cfg.ShutdownTimeLimit != 7*time.Second {
+		t.Fatalf("environment defaults not applied: User = %q, ShutdownTimeLimit = %v", cfg.User, cfg.ShutdownTimeLimit)
+	}
+	if f := fs.Lookup("shutdowntimelimit"); f == nil || f.DefValue != "7s" {
+		t.Fatalf("shutdowntimelimit flag = %+v, want default 7s", f)
+	}
+
+	args := []string{"-datadir", "/srv/flag", "-datadirmode=0750", "-landlock", "-groups", "a,b", "-draindelay", "2s"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DataDir != "/srv/flag" || cfg.DataDirMode != 0o750 || !cfg.Landlock || cfg.DrainDelay != 2*time.Second {
+		t.Errorf("flags not applied: DataDir = %q, DataDirMode = %#o, Landlock = %v, DrainDelay = %v",
+			cfg.DataDir, cfg.DataDirMode, cfg.Landlock, cfg.DrainDelay)
+	}
+	if !slices.Equal(cfg.Groups, []string{"a", "b"}) {
+		t.Errorf("Groups = %q", cfg.Groups)
+	}
+	if cfg.User != "env-user" || cfg.CertDir != "/etc/certs" || cfg.ShutdownTimeLimit != 7*time.Second {
+		t.Errorf("defaults lost: User = %q, CertDir = %q, ShutdownTimeLimit = %v", cfg.User, cfg.CertDir, cfg.ShutdownTimeLimit)
+	}
+}
+
+func TestConfigRegisterFlags_RejectsInvalidFlag(t *testing.T) {
+	cfg := &webserv.Config{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	cfg.RegisterFlags(fs, "")
+	if err := fs.Parse([]string{"-umask", "0888"}); err == nil {
+		t.Fatal("Parse accepted an invalid mode")
+	}
+	if cfg.Umask != 0 {
+		t.Fatalf("Umask = %#o after invalid flag", cfg.Umask)
+	}
+}
+
+func TestConfigRegisterFlags_InvalidEnvironmentLogged(t *testing.T) {
+	t.Setenv("APP_MAXOPENFILES", "lots")
+	var buf bytes.Buffer
+	cfg := &webserv.Config{MaxOpenFiles: 1024, Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+	cfg.RegisterFlags(flag.NewFlagSet("test", flag.ContinueOnError), "APP")
+	if cfg.MaxOpenFiles != 1024 {
+		t.Errorf("MaxOpenFiles = %d, want unchanged 1024", cfg.MaxOpenFiles)
+	}
+	if !strings.Contains(buf.String(), "environment variable ignored") || !strings.Contains(buf.String(), "APP_MAXOPENFILES") {
+		t.Errorf("log = %q, want a warning naming APP_MAXOPENFILES", buf.String())
+	}
+}
+
+func TestConfigRegisterFlags_Usage(t *testing.T) {
+	cfg := &webserv.Config{User: "www-data"}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.RegisterFlags(fs, "WEBSERV")
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+	usage := buf.String()
+	for _, want := range []string{"-address", "$WEBSERV_ADDRESS", "-datadirmode", "-signals", "(default www-data)"} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("usage does not contain %q:\n%s", want, usage)
+		}
+	}
+	if strings.Contains(usage, "-logger") || strings.Contains(usage, "(default 0") {
+		t.Errorf("usage lists a logger flag or zero defaults:\n%s", usage)
+	}
+}
@@ -0,0 +1,248 @@
+package webserv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultHealthCheckTimeout = time.Second
+
+// Health status values used in [HealthReport] and [CheckResult].
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// Health serves liveness and readiness endpoints for a [Config], and keeps a
+// registry of named checks that readiness depends on.
+//
+// Liveness only reflects the lifecycle state: it fails once the [Config] is in
+// [StateFailed]. Readiness additionally requires the [Config] to be in
+// [StateServing], so it fails from the moment a shutdown is requested while
+// [Config.DrainDelay] keeps serving, and requires every check to pass.
+//
+// Set the exported fields before serving. A Health is safe for concurrent
+// use.
+type Health struct {
+	Timeout  time.Duration // maximum time each check may take; zero uses a 1 second default
+	CacheTTL time.Duration // if nonzero, check results are reused for this long instead of running the check on every request
+
+	cfg    *Config
+	mu     sync.Mutex
+	checks map[string]*healthCheck
+}
+
+// healthCheck is a registered check and its last result.
+type healthCheck struct {
+	fn      func(ctx context.Context) error
+	mu      sync.Mutex // guards result and running
+	result  CheckResult
+	running chan struct{} // closed when the run in progress, if any, finishes
+}
+
+// CheckResult is the outcome of one named check in a [HealthReport].
+type CheckResult struct {
+	Status   string    `json:"status"`          // HealthOK or HealthUnavailable
+	Error    string    `json:"error,omitempty"` // the error returned by the check, if any
+	Duration string    `json:"duration"`        // how long the check took
+	Time     time.Time `json:"time"`            // when the check ran
+}
+
+// HealthReport is the JSON document served by the [Health] handlers.
+type HealthReport struct {
+	Status string                 `json:"status"`           // HealthOK or HealthUnavailable
+	State  string                 `json:"state"`            // the lifecycle state of the Config
+	Checks map[string]CheckResult `json:"checks,omitempty"` // results of the named checks, for readiness
+}
+
+// NewHealth returns a [Health] for cfg. If cfg is nil, liveness always
+// succeeds and readiness depends only on the checks.
+func NewHealth(cfg *Config) *Health {
+	return &Health{cfg: cfg}
+}
+
+// AddCheck registers fn as the readiness check called name, replacing any
+// check registered under that name before. fn is called with a context
+// bounded by h.Timeout and must return nil if healthy. The context carries
+// the values of the request but is not canceled when the client goes away.
+// If fn has not returned when h.Timeout expires, or if it panics, the check
+// is reported unavailable; a fn that ignores its context is left running in
+// the background until it returns.
+func (h *Health) AddCheck(name string, fn func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.checks == nil {
+		h.checks = make(map[string]*healthCheck)
+	}
+	h.checks[name] = &healthCheck{fn: fn}
+}
+
+// RemoveCheck unregisters the check called name.
+func (h *Health) RemoveCheck(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.checks, name)
+}
+
+func (h *Health) timeout() (timeout time.Duration) {
+	if timeout = h.Timeout; timeout == 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	return
+}
+
+func (h *Health) state() (state State) {
+	state = StateServing
+	if h.cfg != nil {
+		state = h.cfg.State()
+	}
+	return
+}
+
+// run returns the result of c, running it unless a result younger than
+// h.CacheTTL is available. Requests arriving while the check runs wait for
+// that run and share its result rather than starting another, so a slow check
+// runs at most once at a time however often it is probed, unless it outlives
+// h.Timeout. The check does not see ctx being canceled, since its result may
+// be served to other requests.
+func (h *Health) run(ctx context.Context, c *healthCheck) (result CheckResult) {
+	c.mu.Lock()
+	running := c.running
+	fresh := !c.result.Time.IsZero() && time.Since(c.result.Time) < h.CacheTTL
+	if running == nil && !fresh {
+		running = make(chan struct{})
+		c.running = running
+		c.mu.Unlock()
+		result = h.check(ctx, c.fn)
+		c.mu.Lock()
+		c.result = result
+		c.running = nil
+		close(running)
+	} else if running != nil {
+		c.mu.Unlock()
+		<-running
+		c.mu.Lock()
+	}
+	result = c.result
+	c.mu.Unlock()
+	return
+}
+
+// check calls fn bounded by h.Timeout and returns its result. fn runs in its
+// own goroutine so that one ignoring its context cannot block the caller past
+// the timeout, and a panic in fn is reported as the check's error.
+func (h *Health) check(ctx context.Context, fn func(ctx context.Context) error) (result CheckResult) {
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.timeout())
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- fn(checkCtx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-checkCtx.Done():
+	}
+	if err == nil {
+		err = checkCtx.Err()
+	}
+	result = CheckResult{Status: HealthOK, Duration: time.Since(start).String(), Time: start}
+	if err != nil {
+		result.Status = HealthUnavailable
+		result.Error = err.Error()
+	}
+	return
+}
+
+// Live reports the liveness of the Config.
+func (h *Health) Live() (report HealthReport) {
+	state := h.state()
+	report = HealthReport{Status: HealthOK, State: state.String()}
+	if state == StateFailed {
+		report.Status = HealthUnavailable
+	}
+	return
+}
+
+// Ready runs the registered checks concurrently (or reuses their cached
+// results) and reports the readiness of the Config.
+func (h *Health) Ready(ctx context.Context) (report HealthReport) {
+	state := h.state()
+	report = HealthReport{Status: HealthOK, State: state.String()}
+	if state != StateServing {
+		report.Status = HealthUnavailable
+	}
+	h.mu.Lock()
+	checks := maps.Clone(h.checks)
+	h.mu.Unlock()
+	if len(checks) > 0 {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		report.Checks = make(map[string]CheckResult, len(checks))
+		for name, c := range checks {
+			wg.Go(func() {
+				result := h.run(ctx, c)
+				mu.Lock()
+				defer mu.Unlock()
+				report.Checks[name] = result
+				if result.Status != HealthOK {
+					report.Status = HealthUnavailable
+				}
+			})
+		}
+		wg.Wait()
+	}
+	return
+}
+
+// writeReport writes report as JSON with status 200 if it is healthy and 503
+// otherwise.
+func writeReport(w http.ResponseWriter, report HealthReport) {
+	code := http.StatusOK
+	if report.Status != HealthOK {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// LiveHandler returns a handler serving [Health.Live] as JSON, with status
+// 200 when live and 503 otherwise.
+func (h *Health) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeReport(w, h.Live())
+	})
+}
+
+// ReadyHandler returns a handler serving [Health.Ready] as JSON, with status
+// 200 when ready and 503 otherwise. The checks run with the request context.
+func (h *Health) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, h.Ready(r.Context()))
+	})
+}
+
+// Handler returns a handler serving [Health.LiveHandler] at "/livez" and
+// [Health.ReadyHandler] at "/readyz" and "/healthz". Mount it on the main
+// handler for those paths, or use it for a separate admin server run with
+// [Config.ServeGroup].
+func (h *Health) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/livez", h.LiveHandler())
+	mux.Handle("/readyz", h.ReadyHandler())
+	mux.Handle("/healthz", h.ReadyHandler())
+	return mux
+}
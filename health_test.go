@@ -0,0 +1,234 @@
+package webserv_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/linkdata/webserv"
+)
+
+func getHealth(t *testing.T, h http.Handler, path string) (code int, report webserv.HealthReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("GET %s Content-Type = %q, want application/json", path, ct)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("GET %s body %q: %v", path, rec.Body.String(), err)
+	}
+	return rec.Code, report
+}
+
+func TestHealth_ChecksDecideReadiness(t *testing.T) {
+	h := webserv.NewHealth(nil)
+	h.Timeout = 20 * time.Millisecond
+	h.AddCheck("db", func(context.Context) error { return nil })
+	handler := h.Handler()
+
+	code, report := getHealth(t, handler, "/readyz")
+	if code != http.StatusOK || report.Status != webserv.HealthOK || report.Checks["db"].Status != webserv.HealthOK {
+		t.Fatalf("GET /readyz = %d %+v, want 200 ok", code, report)
+	}
+
+	h.AddCheck("queue", func(context.Context) error { return errors.New("queue full") })
+	h.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	code, report = getHealth(t, handler, "/healthz")
+	if code != http.StatusServiceUnavailable || report.Status != webserv.HealthUnavailable {
+		t.Fatalf("GET /healthz = %d %+v, want 503 unavailable", code, report)
+	}
+	if got := report.Checks["queue"]; got.Status != webserv.HealthUnavailable || got.Error != "queue full" {
+		t.Fatalf("queue check = %+v, want unavailable with error", got)
+	}
+	if got := report.Checks["slow"]; got.Status != webserv.HealthUnavailable || got.Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("slow check = %+v, want timed out", got)
+	}
+	if got := report.Checks["db"]; got.Status != webserv.HealthOK {
+		t.Fatalf("db check = %+v, want ok", got)
+	}
+
+	if code, report = getHealth(t, handler, "/livez"); code != http.StatusOK || report.Checks != nil {
+		t.Fatalf("GET /livez = %d %+v, want 200 without checks", code, report)
+	}
+
+	h.RemoveCheck("queue")
+	h.RemoveCheck("slow")
+	if code, _ = getHealth(t, handler, "/readyz"); code != http.StatusOK {
+		t.Fatalf("GET /readyz = %d after removing failing checks, want 200", code)
+	}
+}
+
+func TestHealth_CacheTTLReusesResults(t *testing.T) {
+	var runs atomic.Int32
+	h := webserv.NewHealth(nil)
+	h.CacheTTL = time.Hour
+	h.AddCheck("counted", func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	first := h.Ready(t.Context())
+	second := h.Ready(t.Context())
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("check ran %d times, want once", n)
+	}
+	if !first.Checks["counted"].Time.Equal(second.Checks["counted"].Time) {
+		t.Fatal("cached result has a different time")
+	}
+
+	h.CacheTTL = 0
+	h.Ready(t.Context())
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("check ran %d times without caching, want 2", n)
+	}
+}
+
+func TestHealth_ConcurrentRequestsShareRun(t *testing.T) {
+	var runs atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := webserv.NewHealth(nil)
+	h.AddCheck("slow", func(context.Context) error {
+		if runs.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return nil
+	})
+	const probes = 5
+	reports := make(chan webserv.HealthReport, probes)
+	go func() { reports <- h.Ready(t.Context()) }()
+	<-started
+	for range probes - 1 {
+		go func() { reports <- h.Ready(t.Context()) }()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	var first time.Time
+	for range probes {
+		result := (<-reports).Checks["slow"]
+		if first.IsZero() {
+			first = result.Time
+		}
+		if result.Status != webserv.HealthOK || !result.Time.Equal(first) {
+			t.Fatalf("probe got %+v, want the shared result from %v", result, first)
+		}
+	}
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("check ran %d times for concurrent probes, want once", n)
+	}
+}
+
+func TestHealth_CanceledRequestIsNotCached(t *testing.T) {
+	h := webserv.NewHealth(nil)
+	h.CacheTTL = time.Hour
+	h.AddCheck("slow", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return nil
+		}
+	})
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if report := h.Ready(ctx); report.Checks["slow"].Status != webserv.HealthOK {
+		t.Fatalf("Ready() with canceled request = %+v, want the check unaffected", report)
+	}
+	if report := h.Ready(t.Context()); report.Status != webserv.HealthOK {
+		t.Fatalf("Ready() = %+v after a canceled request, want ok", report)
+	}
+}
+
+func TestHealth_CheckIgnoringContextTimesOut(t *testing.T) {
+	h := webserv.NewHealth(nil)
+	h.Timeout = 50 * time.Millisecond
+	release := make(chan struct{})
+	defer close(release)
+	h.AddCheck("stuck", func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	for range 2 {
+		done := make(chan webserv.HealthReport, 1)
+		go func() { done <- h.Ready(t.Context()) }()
+		select {
+		case report := <-done:
+			if report.Status != webserv.HealthUnavailable || !strings.Contains(report.Checks["stuck"].Error, "deadline") {
+				t.Fatalf("Ready() = %+v, want stuck check timed out", report)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Ready() blocked on a check ignoring its context")
+		}
+	}
+}
+
+func TestHealth_PanickingCheckIsUnavailable(t *testing.T) {
+	h := webserv.NewHealth(nil)
+	h.AddCheck("panics", func(context.Context) error { panic("boom") })
+	report := h.Ready(t.Context())
+	if report.Status != webserv.HealthUnavailable || !strings.Contains(report.Checks["panics"].Error, "boom") {
+		t.Fatalf("Ready() = %+v, want panicking check unavailable", report)
+	}
+}
+
+func TestHealth_ReadinessFollowsLifecycle(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listening := make(chan struct{})
+	var drainingCode int
+	cfg := &webserv.Config{DrainDelay: 10 * time.Millisecond}
+	h := webserv.NewHealth(cfg)
+	cfg.OnListening = func(context.Context) error {
+		close(listening)
+		return nil
+	}
+	cfg.OnShutdownStart = func(context.Context) error {
+		drainingCode, _ = getHealth(t, h.Handler(), "/readyz")
+		return nil
+	}
+
+	if code, report := getHealth(t, h.Handler(), "/readyz"); code != http.StatusServiceUnavailable || report.State != "starting" {
+		t.Fatalf("GET /readyz before serving = %d %+v, want 503 starting", code, report)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, &http.Server{}, l) }()
+	<-listening
+	if code, report := getHealth(t, h.Handler(), "/readyz"); code != http.StatusOK || report.State != "serving" {
+		t.Fatalf("GET /readyz while serving = %d %+v, want 200 serving", code, report)
+	}
+	cancel()
+	<-done
+	if drainingCode != http.StatusServiceUnavailable {
+		t.Fatalf("GET /readyz while draining = %d, want 503", drainingCode)
+	}
+	if code, _ := getHealth(t, h.Handler(), "/livez"); code != http.StatusOK {
+		t.Fatalf("GET /livez after stopping = %d, want 200", code)
+	}
+}
+
+func TestHealth_LivenessFailsAfterFailure(t *testing.T) {
+	cfg := &webserv.Config{Address: "127.0.0.1:99999"}
+	if _, err := cfg.Listen(); err == nil {
+		t.Fatal("Listen() succeeded")
+	}
+	code, report := getHealth(t, webserv.NewHealth(cfg).Handler(), "/livez")
+	if code != http.StatusServiceUnavailable || report.State != "failed" {
+		t.Fatalf("GET /livez = %d %+v, want 503 failed", code, report)
+	}
+}
@@ -0,0 +1,113 @@
+package webserv
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// hijackedConns is the registry of connections handed to [Config.TrackHijacked].
+type hijackedConns struct {
+	mu       sync.Mutex
+	conns    map[net.Conn]func() // registered connections and their notify functions
+	stopping bool                // set once a shutdown has begun
+	idle     chan struct{}       // closed when conns becomes empty, if someone waits
+}
+
+// TrackHijacked registers conn, taken over from the server with
+// [net/http.Hijacker] (such as a WebSocket), so that [Config.ServeWith]
+// includes it in the shutdown. [net/http.Server.Shutdown] neither waits for
+// nor closes hijacked connections.
+//
+// When a shutdown begins, notify (if not nil) is called in its own goroutine
+// so the handler can wind the connection down; if the shutdown has already
+// begun, it is called right away. ServeWith then waits, bounded by
+// [Config.ShutdownTimeLimit], until untrack has been called for every
+// registered connection, and closes those still registered when the time
+// limit expires.
+//
+// Call untrack once the handler is done with conn. It may be called more than
+// once.
+func (cfg *Config) TrackHijacked(conn net.Conn, notify func()) (untrack func()) {
+	h := &cfg.instance().hijacked
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conns == nil {
+		h.conns = make(map[net.Conn]func())
+	}
+	h.conns[conn] = notify
+	if h.stopping && notify != nil {
+		go notify()
+	}
+	return sync.OnceFunc(func() { h.remove(conn) })
+}
+
+func (h *hijackedConns) remove(conn net.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, conn)
+	h.signalIdle()
+}
+
+// signalIdle closes h.idle if no connections remain. h.mu must be held.
+func (h *hijackedConns) signalIdle() {
+	if len(h.conns) == 0 && h.idle != nil {
+		close(h.idle)
+		h.idle = nil
+	}
+}
+
+// stop marks the shutdown as begun and notifies the registered connections.
+func (h *hijackedConns) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopping = true
+	for _, notify := range h.conns {
+		if notify != nil {
+			go notify()
+		}
+	}
+}
+
+// reset clears the mark set by stop, so the registry can be used by the next
+// call to [Config.ServeWith].
+func (h *hijackedConns) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopping = false
+}
+
+// wait waits until no connections are registered or ctx is done.
+func (h *hijackedConns) wait(ctx context.Context) (err error) {
+	var idle chan struct{}
+	h.mu.Lock()
+	if len(h.conns) > 0 {
+		if h.idle == nil {
+			h.idle = make(chan struct{})
+		}
+		idle = h.idle
+	}
+	h.mu.Unlock()
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	return
+}
+
+// closeAll closes and unregisters all registered connections, returning how
+// many there were.
+func (h *hijackedConns) closeAll() (n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn := range h.conns {
+		_ = conn.Close()
+		n++
+	}
+	clear(h.conns)
+	h.signalIdle()
+	return
+}
@@ -0,0 +1,47 @@
+package webserv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenHint returns an operator-facing suggestion for a failure to listen on
+// address, or an empty string if there is nothing useful to add.
+func listenHint(address string, err error) (hint string) {
+	var port int
+	if _, portStr, splitErr := net.SplitHostPort(address); splitErr == nil {
+		port, _ = strconv.Atoi(portStr)
+	}
+	if port > 0 {
+		switch {
+		case errors.Is(err, errAddrInUse):
+			hint = fmt.Sprintf("port %d is already in use", port)
+			if holder := portHolderFn(port); holder != "" {
+				hint += " by " + holder
+			}
+		case errors.Is(err, errAccess):
+			hint = privilegedPortHint(port)
+		}
+	}
+	return
+}
+
+// loadCertHint returns an operator-facing suggestion for a failure to load a
+// certificate key pair, or an empty string if there is nothing useful to add.
+func loadCertHint(err error) (hint string) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		hint = "certificate or key file is missing; check CertDir, FullchainPem and PrivkeyPem"
+	case errors.Is(err, fs.ErrPermission):
+		hint = fmt.Sprintf("certificate or key file is not readable by uid %d; certificates are loaded before switching user", os.Geteuid())
+	case errors.Is(err, errKeyMismatch):
+		hint = "the private key does not belong to the first certificate in the chain"
+	case errors.Is(err, errNoPEMData):
+		hint = "certificate or key file does not contain the expected PEM data"
+	}
+	return
+}
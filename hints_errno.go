@@ -0,0 +1,10 @@
+//go:build !plan9
+
+package webserv
+
+import "syscall"
+
+var (
+	errAddrInUse error = syscall.EADDRINUSE
+	errAccess    error = syscall.EACCES
+)
@@ -0,0 +1,9 @@
+package webserv
+
+import "errors"
+
+// Plan 9 reports network errors as strings, so these never match.
+var (
+	errAddrInUse = errors.New("address in use")
+	errAccess    = errors.New("permission denied")
+)
@@ -0,0 +1,86 @@
+//go:build linux
+
+package webserv
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	procRoot     = "/proc"
+	portHolderFn = portHolder
+)
+
+// unprivilegedPortStart returns the value of the
+// net.ipv4.ip_unprivileged_port_start sysctl, or 1024 if it cannot be read.
+func unprivilegedPortStart() (start int) {
+	start = 1024
+	if b, err := os.ReadFile(filepath.Join(procRoot, "sys/net/ipv4/ip_unprivileged_port_start")); err == nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(string(b))); err == nil {
+			start = n
+		}
+	}
+	return
+}
+
+func privilegedPortHint(port int) (hint string) {
+	if start := unprivilegedPortStart(); port < start {
+		hint = fmt.Sprintf("binding port %d requires root or CAP_NET_BIND_SERVICE (net.ipv4.ip_unprivileged_port_start is %d); "+
+			"grant it with setcap cap_net_bind_service=+ep or systemd AmbientCapabilities=CAP_NET_BIND_SERVICE", port, start)
+	}
+	return
+}
+
+// listeningSocketInodes returns the inodes of TCP sockets in the LISTEN state
+// bound to port, as found in /proc/net/tcp and /proc/net/tcp6.
+func listeningSocketInodes(port int) (inodes map[string]struct{}) {
+	inodes = make(map[string]struct{})
+	wantPort := fmt.Sprintf(":%04X", port)
+	for _, name := range []string{"net/tcp", "net/tcp6"} {
+		if f, err := os.Open(filepath.Join(procRoot, name)); err == nil {
+			sc := bufio.NewScanner(f)
+			for sc.Scan() {
+				// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+				if fields := strings.Fields(sc.Text()); len(fields) > 9 {
+					if fields[3] == "0A" && strings.HasSuffix(fields[1], wantPort) {
+						inodes[fields[9]] = struct{}{}
+					}
+				}
+			}
+			_ = f.Close()
+		}
+	}
+	return
+}
+
+// portHolder returns a description like "pid 123 (nginx)" of a process that
+// holds a listening socket on port, or an empty string if none can be found.
+// Sockets owned by other users are only found when running with enough
+// privileges to read their /proc/PID/fd entries.
+func portHolder(port int) (holder string) {
+	if inodes := listeningSocketInodes(port); len(inodes) > 0 {
+		fds, _ := filepath.Glob(filepath.Join(procRoot, "[0-9]*", "fd", "*"))
+		for i := 0; i < len(fds) && holder == ""; i++ {
+			if target, err := os.Readlink(fds[i]); err == nil {
+				if inode, ok := strings.CutPrefix(target, "socket:["); ok {
+					if _, found := inodes[strings.TrimSuffix(inode, "]")]; found {
+						pidDir := filepath.Dir(filepath.Dir(fds[i]))
+						holder = "pid " + filepath.Base(pidDir)
+						if comm, err := os.ReadFile(filepath.Join(pidDir, "comm")); err == nil {
+							holder += " (" + strings.TrimSpace(string(comm)) + ")"
+						}
+					}
+				}
+			}
+		}
+		if holder == "" {
+			holder = "a process this user cannot inspect"
+		}
+	}
+	return
+}
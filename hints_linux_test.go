@@ -0,0 +1,80 @@
+//go:build linux
+
+package webserv
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestPortHolder_FakeProc(t *testing.T) {
+	saved := procRoot
+	defer func() { procRoot = saved }()
+	procRoot = t.TempDir()
+
+	mustWrite := func(name, data string) {
+		t.Helper()
+		path := filepath.Join(procRoot, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const header = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	mustWrite("net/tcp", header+
+		"   0: 0100007F:1F90 00000000:0000 01 00000000:00000000 00:00000000 00000000  1000        0 11111 1 0 20 4 30 10 -1\n"+
+		"   1: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 22222 1 0 20 4 30 10 -1\n")
+	mustWrite("net/tcp6", header)
+	mustWrite("sys/net/ipv4/ip_unprivileged_port_start", "1000\n")
+	mustWrite("4242/comm", "nginx\n")
+	if err := os.MkdirAll(filepath.Join(procRoot, "4242", "fd"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("socket:[22222]", filepath.Join(procRoot, "4242", "fd", "7")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := portHolder(8080), "pid 4242 (nginx)"; got != want {
+		t.Fatalf("portHolder(8080) = %q, want %q", got, want)
+	}
+	if got := portHolder(8081); got != "" {
+		t.Fatalf("portHolder(8081) = %q, want empty", got)
+	}
+	if got := unprivilegedPortStart(); got != 1000 {
+		t.Fatalf("unprivilegedPortStart() = %d, want 1000", got)
+	}
+	if hint := privilegedPortHint(443); !strings.Contains(hint, "CAP_NET_BIND_SERVICE") || !strings.Contains(hint, "is 1000") {
+		t.Fatalf("privilegedPortHint(443) = %q", hint)
+	}
+	if hint := privilegedPortHint(1000); hint != "" {
+		t.Fatalf("privilegedPortHint(1000) = %q, want empty", hint)
+	}
+}
+
+func TestPortHolder_FindsOwnListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	if _, err = os.Stat("/proc/self/fd"); err != nil {
+		t.Skipf("procfs unavailable: %v", err)
+	}
+
+	port := l.Addr().(*net.TCPAddr).Port
+	holder := portHolder(port)
+	if want := "pid " + strconv.Itoa(os.Getpid()) + " "; !strings.HasPrefix(holder, want) {
+		t.Fatalf("portHolder(%d) = %q, want prefix %q", port, holder, want)
+	}
+	hint := listenHint(l.Addr().String(), syscall.EADDRINUSE)
+	if !strings.Contains(hint, holder) {
+		t.Fatalf("listenHint() = %q, want mention of %q", hint, holder)
+	}
+}
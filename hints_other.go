@@ -0,0 +1,14 @@
+//go:build !linux
+
+package webserv
+
+import "fmt"
+
+var portHolderFn = func(int) string { return "" }
+
+func privilegedPortHint(port int) (hint string) {
+	if port < 1024 {
+		hint = fmt.Sprintf("binding port %d usually requires root privileges", port)
+	}
+	return
+}
@@ -0,0 +1,69 @@
+package webserv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadCertHint(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "missing", err: &fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist}, want: "missing"},
+		{name: "permission", err: &fs.PathError{Op: "open", Path: "x", Err: fs.ErrPermission}, want: "not readable"},
+		{name: "mismatch", err: fmt.Errorf("a, b: %w", errKeyMismatch), want: "does not belong"},
+		{name: "not pem", err: fmt.Errorf("a: %w", errNoPEMData), want: "PEM"},
+		{name: "tls text is not parsed", err: errors.New("tls: private key does not match public key"), want: ""},
+		{name: "other", err: errors.New("boom"), want: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := loadCertHint(tc.err)
+			if (tc.want == "") != (got == "") || !strings.Contains(got, tc.want) {
+				t.Fatalf("loadCertHint(%v) = %q, want containing %q", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestListenHint_AddressInUseNamesHolder(t *testing.T) {
+	saved := portHolderFn
+	defer func() { portHolderFn = saved }()
+
+	gotPort := 0
+	portHolderFn = func(port int) string {
+		gotPort = port
+		return "pid 42 (nginx)"
+	}
+	err := &net.OpError{Op: "listen", Net: "tcp", Err: os.NewSyscallError("bind", errAddrInUse)}
+	hint := listenHint("127.0.0.1:8080", err)
+	if gotPort != 8080 {
+		t.Fatalf("portHolderFn called with port %d, want 8080", gotPort)
+	}
+	if want := "port 8080 is already in use by pid 42 (nginx)"; hint != want {
+		t.Fatalf("listenHint() = %q, want %q", hint, want)
+	}
+}
+
+func TestListenHint_NoHintWithoutPortOrKnownErrno(t *testing.T) {
+	if hint := listenHint("[]", net.InvalidAddrError("[]")); hint != "" {
+		t.Fatalf("listenHint() = %q, want empty", hint)
+	}
+	if hint := listenHint("127.0.0.1:8080", errors.New("boom")); hint != "" {
+		t.Fatalf("listenHint() = %q, want empty", hint)
+	}
+}
+
+func TestListenError_ErrorIncludesHint(t *testing.T) {
+	err := ListenError{Address: ":80", Hint: "some hint", Err: errors.New("boom")}
+	if got, want := err.Error(), `Listen(":80"): boom (some hint)`; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,21 @@
+package webserv
+
+import (
+	"context"
+	"fmt"
+)
+
+// runHook calls the lifecycle callback fn, if set, with a context that keeps
+// the values of ctx but not its cancellation, bounded by
+// cfg.ShutdownTimeLimit. A failure is logged and returned prefixed with name.
+func (cfg *Config) runHook(ctx context.Context, name string, fn func(ctx context.Context) error) (err error) {
+	if fn != nil {
+		hookCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.shutdownTimeLimit())
+		defer cancel()
+		if err = fn(hookCtx); err != nil {
+			err = fmt.Errorf("%s: %w", name, err)
+			cfg.logError("callback failed", "callback", name, "err", err)
+		}
+	}
+	return
+}
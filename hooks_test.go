@@ -0,0 +1,123 @@
+package webserv_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestConfigServeWith_LifecycleCallbacksRunInOrder(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const limit = 500 * time.Millisecond
+	var mu sync.Mutex
+	var calls []string
+	listening := make(chan struct{})
+	errDrained := errors.New("drained failed")
+	errStopped := errors.New("stopped failed")
+	hook := func(name string, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			if !ok || time.Until(deadline) > limit {
+				t.Errorf("%s deadline = %v, %v; want within %v", name, deadline, ok, limit)
+			}
+			if ctx.Err() != nil {
+				t.Errorf("%s context already done: %v", name, ctx.Err())
+			}
+			mu.Lock()
+			calls = append(calls, name)
+			mu.Unlock()
+			if name == "OnListening" {
+				close(listening)
+			}
+			return err
+		}
+	}
+	cfg := &webserv.Config{
+		ShutdownTimeLimit: limit,
+		OnListening:       hook("OnListening", nil),
+		OnShutdownStart:   hook("OnShutdownStart", nil),
+		OnDrained:         hook("OnDrained", errDrained),
+		OnStopped:         hook("OnStopped", errStopped),
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, &http.Server{}, l) }()
+	<-listening
+	cancel()
+
+	err = <-done
+	for _, want := range []error{context.Canceled, errDrained, errStopped} {
+		if !errors.Is(err, want) {
+			t.Errorf("ServeWith() error = %v, want match %v", err, want)
+		}
+	}
+	want := []string{"OnListening", "OnShutdownStart", "OnDrained", "OnStopped"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("callbacks = %v, want %v", calls, want)
+	}
+}
+
+func TestConfigServeWith_OnListeningErrorShutsDown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errRegister := errors.New("register failed")
+	var stopped bool
+	cfg := &webserv.Config{
+		OnListening: func(context.Context) error { return errRegister },
+		OnStopped: func(context.Context) error {
+			stopped = true
+			return nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(t.Context(), &http.Server{}, l) }()
+
+	select {
+	case err = <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("ServeWith() did not return after OnListening failed")
+	}
+	if !errors.Is(err, errRegister) {
+		t.Fatalf("ServeWith() error = %v, want match %v", err, errRegister)
+	}
+	if !stopped {
+		t.Fatal("OnStopped was not called")
+	}
+}
+
+func TestConfigServeWith_OnStoppedCalledWhenServeFails(t *testing.T) {
+	var calls []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	cfg := &webserv.Config{
+		OnShutdownStart: record("OnShutdownStart"),
+		OnDrained:       record("OnDrained"),
+		OnStopped:       record("OnStopped"),
+	}
+	err := cfg.ServeWith(t.Context(), &http.Server{}, panicListener{})
+	if !errors.Is(err, webserv.ErrServePanic) {
+		t.Fatalf("ServeWith() error = %v, want match %v", err, webserv.ErrServePanic)
+	}
+	if want := []string{"OnStopped"}; !slices.Equal(calls, want) {
+		t.Fatalf("callbacks = %v, want %v", calls, want)
+	}
+}
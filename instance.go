@@ -0,0 +1,41 @@
+package webserv
+
+import (
+	"os"
+	"sync"
+)
+
+// instance is the runtime state of a Config, kept apart from the Config so
+// that a Config can be copied as a plain value. Listen starts a new instance;
+// copies of a Config made after that share it.
+type instance struct {
+	lock      *os.File      // lock file held since Listen, if any
+	pidFile   string        // PID file written by Listen, if any
+	dataRoot  *os.Root      // cfg.DataDir, opened by Listen with StrictPaths to access the lock and PID files
+	certs     *certificate  // certificate served by the listener opened by Listen, if any
+	hijacked  hijackedConns // connections registered with TrackHijacked
+	lifecycle lifecycle     // lifecycle state reported by State, Status and Transitions
+}
+
+// instanceMu guards Config.inst.
+var instanceMu sync.Mutex
+
+// instance returns the runtime state of cfg, creating it if Listen has not
+// been called.
+func (cfg *Config) instance() *instance {
+	instanceMu.Lock()
+	defer instanceMu.Unlock()
+	if cfg.inst == nil {
+		cfg.inst = &instance{}
+	}
+	return cfg.inst
+}
+
+// newInstance replaces the runtime state of cfg with a new one and returns it.
+func (cfg *Config) newInstance() (inst *instance) {
+	inst = &instance{}
+	instanceMu.Lock()
+	cfg.inst = inst
+	instanceMu.Unlock()
+	return
+}
@@ -0,0 +1,66 @@
+package webserv
+
+import (
+	"errors"
+	"os"
+)
+
+// Landlock restricts filesystem access of the whole process using the Linux
+// Landlock LSM: read-write access is allowed beneath each path in readWrite,
+// read-only access beneath each path in readOnly, and all other filesystem
+// access handled by the kernel's Landlock ABI is denied. Execution is never
+// allowed. The restriction cannot be lifted and is inherited by child processes.
+//
+// It returns the Landlock ABI version that was enforced. If the kernel or OS
+// does not support Landlock, it returns abi 0 and an error matching both
+// [ErrLandlock] and [errors.ErrUnsupported]. Restricting every thread is not
+// possible in binaries that use cgo; build with CGO_ENABLED=0.
+//
+// Paths that are needed later, for example /etc/resolv.conf and the CA
+// certificates for outgoing connections, must be included in readOnly.
+// All paths must exist.
+//
+// Returns an error matching [ErrLandlock] on failure.
+func Landlock(readWrite, readOnly []string) (abi int, err error) {
+	abi, err = landlock(readWrite, readOnly)
+	return abi, newErrLandlock(err)
+}
+
+// landlock applies [Landlock] as configured by cfg, also granting access to
+// those of cfg.ConfigDir (read-only), cfg.StateDir, cfg.CacheDir and
+// cfg.RuntimeDir that exist, and read-only access to the certificate files
+// for [Config.Reload]. A cfg.CertDir left outside cfg.Chroot is not reachable
+// any more, so it is skipped with a warning. A kernel without Landlock
+// support is logged as a warning rather than failing.
+func (cfg *Config) landlock() (err error) {
+	readWrite := append([]string(nil), cfg.LandlockWritePaths...)
+	readOnly := append([]string(nil), cfg.LandlockReadPaths...)
+	if cfg.DataDir != "" {
+		readWrite = append(readWrite, cfg.DataDir)
+	}
+	for _, p := range cfg.certReadPaths() {
+		if _, statErr := os.Stat(p); statErr == nil {
+			readOnly = append(readOnly, p)
+		} else {
+			cfg.logWarn("certificates not reachable, Reload will fail", "path", p, "err", statErr)
+		}
+	}
+	for _, d := range cfg.userDirs() {
+		// Directories that were resolved but never created cannot be added.
+		if _, statErr := os.Stat(*d.dir); *d.dir != "" && statErr == nil {
+			if d.readOnly {
+				readOnly = append(readOnly, *d.dir)
+			} else {
+				readWrite = append(readWrite, *d.dir)
+			}
+		}
+	}
+	var abi int
+	if abi, err = Landlock(readWrite, readOnly); err == nil {
+		cfg.logInfo("landlock enforced", "abi", abi)
+	} else if abi == 0 && errors.Is(err, errors.ErrUnsupported) {
+		cfg.logWarn("landlock not enforced", "err", err)
+		err = nil
+	}
+	return
+}
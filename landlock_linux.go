@@ -0,0 +1,139 @@
+//go:build linux
+
+package webserv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+
+	landlockCreateRulesetVersion = 1 << 0
+	landlockRulePathBeneath      = 1
+
+	landlockAccessFSExecute    = 1 << 0
+	landlockAccessFSWriteFile  = 1 << 1
+	landlockAccessFSReadFile   = 1 << 2
+	landlockAccessFSReadDir    = 1 << 3
+	landlockAccessFSRefer      = 1 << 13
+	landlockAccessFSTruncate   = 1 << 14
+	landlockAccessFSIoctlDev   = 1 << 15
+	landlockAccessFSABI1       = 1<<13 - 1 // EXECUTE through MAKE_SYM
+	landlockAccessFSFileRights = landlockAccessFSExecute | landlockAccessFSWriteFile | landlockAccessFSReadFile | landlockAccessFSTruncate | landlockAccessFSIoctlDev
+	landlockAccessFSReadOnly   = landlockAccessFSReadFile | landlockAccessFSReadDir
+
+	oPath = 0x200000 // O_PATH, not defined by package syscall on all architectures
+)
+
+type landlockRulesetAttr struct {
+	handledAccessFS uint64
+}
+
+// landlockPathBeneathAttr mirrors the packed kernel struct; only its first 12
+// bytes are read.
+type landlockPathBeneathAttr struct {
+	allowedAccess uint64
+	parentFd      int32
+}
+
+var (
+	landlockABIFn      = landlockABI
+	landlockRestrictFn = landlockRestrict
+)
+
+// landlockABI returns the Landlock ABI version supported by the kernel, or 0
+// if Landlock is not available.
+func landlockABI() (abi int) {
+	if r, _, e := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion); e == 0 {
+		abi = int(r)
+	}
+	return
+}
+
+// landlockHandledAccessFS returns the filesystem access rights known to the
+// given ABI version.
+func landlockHandledAccessFS(abi int) (access uint64) {
+	access = landlockAccessFSABI1
+	if abi >= 2 {
+		access |= landlockAccessFSRefer
+	}
+	if abi >= 3 {
+		access |= landlockAccessFSTruncate
+	}
+	if abi >= 5 {
+		access |= landlockAccessFSIoctlDev
+	}
+	return
+}
+
+// landlockRestrict creates a ruleset handling all of handled, allows the
+// given access beneath each path, and enforces it on every thread.
+func landlockRestrict(handled uint64, rules map[string]uint64) (err error) {
+	attr := landlockRulesetAttr{handledAccessFS: handled}
+	if r, _, e := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0); e == 0 {
+		rulesetFd := int(r)
+		for path, access := range rules {
+			if err == nil {
+				err = landlockAddPath(rulesetFd, path, access)
+			}
+		}
+		if err == nil {
+			if _, _, e = syscall.AllThreadsSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); e != 0 {
+				err = os.NewSyscallError("prctl", e)
+			} else if _, _, e = syscall.AllThreadsSyscall(sysLandlockRestrictSelf, uintptr(rulesetFd), 0, 0); e != 0 {
+				err = os.NewSyscallError("landlock_restrict_self", e)
+			}
+		}
+		_ = syscall.Close(rulesetFd)
+	} else {
+		err = os.NewSyscallError("landlock_create_ruleset", e)
+	}
+	return
+}
+
+func landlockAddPath(rulesetFd int, path string, access uint64) (err error) {
+	var fd int
+	if fd, err = syscall.Open(path, oPath|syscall.O_CLOEXEC, 0); err == nil {
+		var st syscall.Stat_t
+		if err = syscall.Fstat(fd, &st); err == nil {
+			if st.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+				access &= landlockAccessFSFileRights
+			}
+			attr := landlockPathBeneathAttr{allowedAccess: access, parentFd: int32(fd)}
+			if _, _, e := syscall.Syscall6(sysLandlockAddRule, uintptr(rulesetFd), landlockRulePathBeneath, uintptr(unsafe.Pointer(&attr)), 0, 0, 0); e != 0 {
+				err = os.NewSyscallError("landlock_add_rule", e)
+			}
+		}
+		_ = syscall.Close(fd)
+	}
+	if err != nil {
+		err = &os.PathError{Op: "landlock", Path: path, Err: err}
+	}
+	return
+}
+
+func landlock(readWrite, readOnly []string) (abi int, err error) {
+	if abi = landlockABIFn(); abi > 0 {
+		handled := landlockHandledAccessFS(abi)
+		rules := make(map[string]uint64)
+		for _, path := range readOnly {
+			rules[path] |= landlockAccessFSReadOnly
+		}
+		for _, path := range readWrite {
+			rules[path] |= handled &^ landlockAccessFSExecute
+		}
+		if err = landlockRestrictFn(handled, rules); errors.Is(err, syscall.ENOTSUP) {
+			err = fmt.Errorf("binaries using cgo cannot restrict all threads; build with CGO_ENABLED=0: %w", err)
+		}
+	} else {
+		err = fmt.Errorf("kernel does not support Landlock: %w", errors.ErrUnsupported)
+	}
+	return
+}
@@ -0,0 +1,239 @@
+//go:build linux
+
+package webserv
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestLandlockHandledAccessFS(t *testing.T) {
+	for _, tc := range []struct {
+		abi  int
+		want uint64
+	}{
+		{abi: 1, want: 1<<13 - 1},
+		{abi: 2, want: 1<<14 - 1},
+		{abi: 3, want: 1<<15 - 1},
+		{abi: 4, want: 1<<15 - 1},
+		{abi: 5, want: 1<<16 - 1},
+		{abi: 7, want: 1<<16 - 1},
+	} {
+		if got := landlockHandledAccessFS(tc.abi); got != tc.want {
+			t.Errorf("landlockHandledAccessFS(%d) = %#x, want %#x", tc.abi, got, tc.want)
+		}
+	}
+}
+
+func TestConfigLandlock_Rules(t *testing.T) {
+	savedABI, savedRestrict := landlockABIFn, landlockRestrictFn
+	defer func() { landlockABIFn, landlockRestrictFn = savedABI, savedRestrict }()
+
+	var gotHandled uint64
+	var gotRules map[string]uint64
+	landlockABIFn = func() int { return 3 }
+	landlockRestrictFn = func(handled uint64, rules map[string]uint64) error {
+		gotHandled, gotRules = handled, rules
+		return nil
+	}
+	rl := &recordingLogger{}
+	certDir := t.TempDir()
+	cfg := &Config{
+		DataDir:            "/data",
+		CertDir:            certDir,
+		LandlockReadPaths:  []string{"/etc/resolv.conf", "/data"},
+		LandlockWritePaths: []string{"/tmp"},
+		Logger:             rl,
+	}
+	if err := cfg.landlock(); err != nil {
+		t.Fatal(err)
+	}
+	readWrite := landlockHandledAccessFS(3) &^ landlockAccessFSExecute
+	want := map[string]uint64{
+		"/data":            readWrite | landlockAccessFSReadOnly,
+		"/tmp":             readWrite,
+		certDir:            landlockAccessFSReadOnly,
+		"/etc/resolv.conf": landlockAccessFSReadOnly,
+	}
+	if gotHandled != landlockHandledAccessFS(3) || !reflect.DeepEqual(gotRules, want) {
+		t.Fatalf("handled=%#x rules=%#v, want %#v", gotHandled, gotRules, want)
+	}
+	if !reflect.DeepEqual(rl.messages, []string{"webserv: landlock enforced"}) {
+		t.Fatalf("messages = %v", rl.messages)
+	}
+}
+
+func TestConfigLandlock_UnreachableCertDirWarns(t *testing.T) {
+	savedABI, savedRestrict := landlockABIFn, landlockRestrictFn
+	defer func() { landlockABIFn, landlockRestrictFn = savedABI, savedRestrict }()
+
+	var gotRules map[string]uint64
+	landlockABIFn = func() int { return 3 }
+	landlockRestrictFn = func(_ uint64, rules map[string]uint64) error {
+		gotRules = rules
+		return nil
+	}
+	rl := &recordingLogger{}
+	certDir := filepath.Join(t.TempDir(), "missing")
+	cfg := &Config{DataDir: "/data", CertDir: certDir, Logger: rl}
+	if err := cfg.landlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gotRules[certDir]; ok {
+		t.Fatalf("rules = %v, want %q skipped", gotRules, certDir)
+	}
+	if !reflect.DeepEqual(rl.warnings, []string{"webserv: certificates not reachable, Reload will fail"}) {
+		t.Fatalf("warnings = %v", rl.warnings)
+	}
+}
+
+func TestConfigLandlock_UnsupportedKernelWarns(t *testing.T) {
+	savedABI, savedRestrict := landlockABIFn, landlockRestrictFn
+	defer func() { landlockABIFn, landlockRestrictFn = savedABI, savedRestrict }()
+
+	landlockABIFn = func() int { return 0 }
+	landlockRestrictFn = func(uint64, map[string]uint64) error {
+		t.Fatal("restrict called without Landlock support")
+		return nil
+	}
+	rl := &recordingLogger{}
+	cfg := &Config{DataDir: "/data", Logger: rl}
+	if err := cfg.landlock(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rl.warnings, []string{"webserv: landlock not enforced"}) {
+		t.Fatalf("warnings = %v", rl.warnings)
+	}
+
+	abi, err := Landlock(nil, nil)
+	if abi != 0 || !errors.Is(err, ErrLandlock) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Landlock() = (%d, %v), want (0, match %v and %v)", abi, err, ErrLandlock, errors.ErrUnsupported)
+	}
+}
+
+func TestConfigLandlock_RestrictFailureIsError(t *testing.T) {
+	savedABI, savedRestrict := landlockABIFn, landlockRestrictFn
+	defer func() { landlockABIFn, landlockRestrictFn = savedABI, savedRestrict }()
+
+	landlockABIFn = func() int { return 1 }
+	landlockRestrictFn = func(uint64, map[string]uint64) error { return syscall.ENOTSUP }
+	cfg := &Config{DataDir: "/data"}
+	if err := cfg.landlock(); !errors.Is(err, ErrLandlock) {
+		t.Fatalf("landlock() = %v, want match %v", err, ErrLandlock)
+	}
+}
+
+// TestLandlock_Process enforces Landlock for real in a child process, since
+// the restriction is irreversible. It is skipped if the kernel lacks Landlock
+// or the binary uses cgo.
+func TestLandlock_Process(t *testing.T) {
+	if dir := os.Getenv("WEBSERV_LANDLOCK_CHILD"); dir != "" {
+		dataDir := filepath.Join(dir, "data")
+		certDir := filepath.Join(dir, "certs")
+		abi, err := Landlock([]string{dataDir}, []string{certDir})
+		if errors.Is(err, errors.ErrUnsupported) {
+			os.Exit(3)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if abi < 1 {
+			t.Fatalf("abi = %d", abi)
+		}
+		if err = os.WriteFile(filepath.Join(dataDir, "state"), []byte("ok"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = os.ReadFile(filepath.Join(certDir, "cert")); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(filepath.Join(certDir, "cert"), nil, 0o600); !errors.Is(err, os.ErrPermission) {
+			t.Fatalf("write in read-only path = %v, want permission error", err)
+		}
+		if _, err = os.ReadFile(filepath.Join(dir, "outside")); !errors.Is(err, os.ErrPermission) {
+			t.Fatalf("read outside = %v, want permission error", err)
+		}
+		return
+	}
+
+	dir := t.TempDir()
+	for _, sub := range []string{"data", "certs"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"certs/cert", "outside"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLandlock_Process$")
+	cmd.Env = append(os.Environ(), "WEBSERV_LANDLOCK_CHILD="+dir)
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 3 {
+		t.Skip("Landlock unavailable in this kernel or binary (cgo)")
+	}
+	if err != nil {
+		t.Fatalf("child failed: %v\n%s", err, output)
+	}
+}
+
+// TestConfigChrootLandlock_Process chroots and then enforces Landlock in a
+// child process, with cfg.CertDir outside the chroot as certificate managers
+// usually lay it out. It is skipped unless run as root on a kernel with
+// Landlock and without cgo.
+func TestConfigChrootLandlock_Process(t *testing.T) {
+	if root := os.Getenv("WEBSERV_CHROOT_LANDLOCK_CHILD"); root != "" {
+		if landlockABIFn() < 1 {
+			os.Exit(3)
+		}
+		rl := &recordingLogger{}
+		cfg := &Config{
+			Chroot:   root,
+			DataDir:  filepath.Join(root, "data"),
+			CertDir:  os.Getenv("WEBSERV_CHROOT_LANDLOCK_CERTS"),
+			Landlock: true,
+			Logger:   rl,
+		}
+		err := cfg.chroot()
+		if err == nil {
+			err = cfg.landlock()
+		}
+		if errors.Is(err, errors.ErrUnsupported) {
+			os.Exit(3)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(rl.warnings, []string{"webserv: certificates not reachable, Reload will fail"}) {
+			t.Fatalf("warnings = %v", rl.warnings)
+		}
+		if err = os.WriteFile("/data/state", []byte("ok"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if os.Geteuid() != 0 {
+		t.Skip("chroot requires root")
+	}
+	root, certs := t.TempDir(), t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "data"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestConfigChrootLandlock_Process$")
+	cmd.Env = append(os.Environ(), "WEBSERV_CHROOT_LANDLOCK_CHILD="+root, "WEBSERV_CHROOT_LANDLOCK_CERTS="+certs)
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 3 {
+		t.Skip("Landlock unavailable in this kernel or binary (cgo)")
+	}
+	if err != nil {
+		t.Fatalf("child failed: %v\n%s", err, output)
+	}
+}
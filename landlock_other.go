@@ -0,0 +1,9 @@
+//go:build !linux
+
+package webserv
+
+import "errors"
+
+func landlock([]string, []string) (int, error) {
+	return 0, errors.ErrUnsupported
+}
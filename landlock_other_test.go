@@ -0,0 +1,20 @@
+//go:build !linux
+
+package webserv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestLandlock_UnsupportedOS(t *testing.T) {
+	abi, err := webserv.Landlock(nil, nil)
+	if abi != 0 {
+		t.Errorf("abi = %d, want 0", abi)
+	}
+	if !errors.Is(err, webserv.ErrLandlock) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("error %v does not match webserv.ErrLandlock and errors.ErrUnsupported", err)
+	}
+}
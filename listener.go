@@ -7,6 +7,7 @@ import (
 	"net/netip"
 	"os"
 	"strings"
+	"sync/atomic"
 )
 
 const (
@@ -19,10 +20,12 @@ const (
 // Listener creates a [net.Listener] given an optional preferred address
 // and an optional directory containing certificate files.
 //
-// If certDir is not empty, it calls [LoadCert] to load fullchain.pem and privkey.pem.
+// It calls [LoadCert] to load fullchain.pem and privkey.pem from certDir, or
+// from the systemd CREDENTIALS_DIRECTORY if certDir is empty.
 //
 // The listener will default to all addresses and standard port
-// depending on privileges and if a certificate was loaded or not.
+// depending on privileges (root, or CAP_NET_BIND_SERVICE on Linux) and if a
+// certificate was loaded or not.
 //
 // These defaults can be overridden with the listenAddr argument.
 // To specify only a port, use an address like ":8080".
@@ -30,20 +33,42 @@ const (
 // Returns the [net.Listener] and listenURL if there was no error.
 // absCertDir is the resolved absolute path to certDir whenever certDir was
 // non-empty and could be resolved, even if loading the certificate then failed.
+//
+// Certificate failures return an error matching [ErrLoadCert]. An invalid
+// listen address or a failure to open the socket returns an error matching
+// [ErrListen].
 func Listener(listenAddr, certDir, fullchainPem, privkeyPem, overrideUrl string) (l net.Listener, listenUrl, absCertDir string, err error) {
+	return listener(listenAddr, certDir, fullchainPem, privkeyPem, overrideUrl, false, &certificate{})
+}
+
+// certificate holds the certificate served by a TLS listener, so that it can
+// be replaced while serving.
+type certificate struct {
+	atomic.Pointer[tls.Certificate]
+}
+
+func (c *certificate) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.Load(), nil
+}
+
+// listener implements [Listener], loading the certificate with
+// [LoadCertStrict] if strict is set. A TLS listener serves the certificate
+// stored in certs.
+func listener(listenAddr, certDir, fullchainPem, privkeyPem, overrideUrl string, strict bool, certs *certificate) (l net.Listener, listenUrl, absCertDir string, err error) {
 	var cert *tls.Certificate
-	if cert, absCertDir, err = LoadCert(certDir, fullchainPem, privkeyPem); err == nil {
+	if cert, absCertDir, err = loadCert(certDir, fullchainPem, privkeyPem, strict); err == nil {
 		var bindAddr string
 		var schemesuffix string
 		if cert != nil {
 			schemesuffix = "s"
+			certs.Store(cert)
 			if bindAddr, err = normalizeListenAddr(listenAddr, "443", "8443"); err == nil {
 				l, err = tls.Listen(
 					"tcp", bindAddr,
 					&tls.Config{
-						Certificates: []tls.Certificate{*cert},
-						MinVersion:   tls.VersionTLS13,
-						NextProtos:   []string{"h2", "http/1.1"},
+						GetCertificate: certs.getCertificate,
+						MinVersion:     tls.VersionTLS13,
+						NextProtos:     []string{"h2", "http/1.1"},
 					},
 				)
 			}
@@ -52,6 +77,10 @@ func Listener(listenAddr, certDir, fullchainPem, privkeyPem, overrideUrl string)
 				l, err = net.Listen("tcp", bindAddr)
 			}
 		}
+		if bindAddr == "" {
+			bindAddr = listenAddr
+		}
+		err = newErrListen(bindAddr, err)
 		if l != nil {
 			if listenUrl = overrideUrl; listenUrl == "" {
 				listenUrl = fmt.Sprintf("http%s://%s", schemesuffix, listenUrlString(l, cert))
@@ -86,12 +115,12 @@ func normalizeListenAddr(address, defaultpriv, defaultother string) (string, err
 		}
 		host = lit
 	}
-	return net.JoinHostPort(host, defaultListenPort(os.Geteuid(), defaultpriv, defaultother)), nil
+	return net.JoinHostPort(host, defaultListenPort(canBindPrivilegedPorts(os.Geteuid()), defaultpriv, defaultother)), nil
 }
 
-func defaultListenPort(euid int, defaultpriv, defaultother string) (port string) {
+func defaultListenPort(privileged bool, defaultpriv, defaultother string) (port string) {
 	port = defaultother
-	if euid == 0 {
+	if privileged {
 		port = defaultpriv
 	}
 	return
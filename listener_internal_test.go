@@ -89,23 +89,37 @@ func TestNormalizeListenAddr_MalformedBracketResultIsEmpty(t *testing.T) {
 	}
 }
 
-func TestDefaultListenPort_RootUsesPrivilegedDefault(t *testing.T) {
-	if got := defaultListenPort(0, "80", "8080"); got != "80" {
-		t.Fatalf("defaultListenPort(0) = %q, want %q", got, "80")
+func TestDefaultListenPort_PrivilegedUsesPrivilegedDefault(t *testing.T) {
+	if got := defaultListenPort(true, "80", "8080"); got != "80" {
+		t.Fatalf("defaultListenPort(true) = %q, want %q", got, "80")
 	}
 }
 
-func TestDefaultListenPort_NonRootUsesOtherDefault(t *testing.T) {
-	if got := defaultListenPort(1000, "80", "8080"); got != "8080" {
-		t.Fatalf("defaultListenPort(1000) = %q, want %q", got, "8080")
+func TestDefaultListenPort_UnprivilegedUsesOtherDefault(t *testing.T) {
+	if got := defaultListenPort(false, "80", "8080"); got != "8080" {
+		t.Fatalf("defaultListenPort(false) = %q, want %q", got, "8080")
 	}
 }
 
-func TestDefaultListenPort_NegativeEUIDUsesOtherDefault(t *testing.T) {
+func TestCanBindPrivilegedPorts(t *testing.T) {
+	saved := hasCapabilityFn
+	defer func() { hasCapabilityFn = saved }()
+
+	hasNetBind := false
+	hasCapabilityFn = func(c int) bool { return c == capNetBindService && hasNetBind }
+	if !canBindPrivilegedPorts(0) {
+		t.Fatal("canBindPrivilegedPorts(0) = false, want true for root")
+	}
 	// A negative euid (such as the -1 os.Geteuid returns on Windows) is not
 	// root and must use the unprivileged default port.
-	if got := defaultListenPort(-1, "80", "8080"); got != "8080" {
-		t.Fatalf("defaultListenPort(-1) = %q, want %q", got, "8080")
+	for _, euid := range []int{1000, -1} {
+		if canBindPrivilegedPorts(euid) {
+			t.Fatalf("canBindPrivilegedPorts(%d) = true without CAP_NET_BIND_SERVICE", euid)
+		}
+	}
+	hasNetBind = true
+	if !canBindPrivilegedPorts(1000) {
+		t.Fatal("canBindPrivilegedPorts(1000) = false with CAP_NET_BIND_SERVICE")
 	}
 }
 
@@ -218,3 +218,46 @@ func TestListener_TLSAdvertisesHTTP2(t *testing.T) {
 		}
 	})
 }
+
+func TestListener_ErrorMatchesErrListen(t *testing.T) {
+	for _, listenAddr := range []string{"127.0.0.1:99999", "[]"} {
+		l, _, _, err := webserv.Listener(listenAddr, "", "", "", "")
+		if l != nil {
+			_ = l.Close()
+		}
+		if !errors.Is(err, webserv.ErrListen) {
+			t.Fatalf("Listener(%q) error = %v, want match %v", listenAddr, err, webserv.ErrListen)
+		}
+		var le webserv.ListenError
+		if !errors.As(err, &le) {
+			t.Fatalf("Listener(%q) error = %T, want ListenError", listenAddr, err)
+		}
+		if le.Address != listenAddr {
+			t.Errorf("ListenError.Address = %q, want %q", le.Address, listenAddr)
+		}
+		if errors.Is(err, webserv.ErrLoadCert) {
+			t.Errorf("Listener(%q) error unexpectedly matches ErrLoadCert", listenAddr)
+		}
+	}
+}
+
+func TestListener_AddressInUseMatchesErrListen(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = busy.Close() }()
+
+	l, _, _, err := webserv.Listener(busy.Addr().String(), "", "", "", "")
+	if l != nil {
+		_ = l.Close()
+		t.Skip("platform allowed a second bind to the same address")
+	}
+	if !errors.Is(err, webserv.ErrListen) {
+		t.Fatalf("Listener() error = %v, want match %v", err, webserv.ErrListen)
+	}
+	var le webserv.ListenError
+	if !errors.As(err, &le) || le.Address != busy.Addr().String() {
+		t.Fatalf("Listener() error = %#v, want ListenError for %q", err, busy.Addr().String())
+	}
+}
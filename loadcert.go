@@ -1,14 +1,31 @@
 package webserv
 
 import (
+	"crypto"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+)
+
+var (
+	errNoPEMData   = errors.New("no PEM data of the expected type")
+	errKeyMismatch = errors.New("private key does not match the first certificate in the chain")
 )
 
 // LoadCert does nothing if certDir is empty, otherwise it expands
 // environment variables and transforms it into an absolute path.
-// It then tries to load a X509 key pair with [crypto/tls.LoadX509KeyPair] from
+//
+// If certDir is empty and the CREDENTIALS_DIRECTORY environment variable names
+// a directory containing fullchainPem (as systemd sets it for units using
+// LoadCredential=), that directory is used instead, as is. This lets the service
+// manager hand over certificates that are not readable by the service user
+// on disk.
+// It then tries to load a X509 key pair as [crypto/tls.LoadX509KeyPair] would from
 // the files named fullchainPem and privkeyPem in the resulting directory.
 //
 // If expansion collapses certDir to empty (for example a lone "$VAR" whose
@@ -17,7 +34,8 @@ import (
 //
 // The filenames may contain paths, ".." segments and symlinks.
 // They are not confined to certDir, so they may resolve outside of it.
-// Caller is responsible for validating or sandboxing untrusted path input.
+// Caller is responsible for validating or sandboxing untrusted path input, or
+// can use [LoadCertStrict].
 //
 // If fullchainPem is empty, it defaults to [FullchainPem].
 // If privkeyPem is empty, it defaults to [PrivkeyPem].
@@ -25,27 +43,152 @@ import (
 // cert is non-nil only when the key pair loaded successfully. absCertDir is the
 // resolved absolute directory whenever certDir was non-empty after expansion and
 // [path/filepath.Abs] succeeded, regardless of whether the key pair then loaded.
+//
+// Returns an error matching [ErrLoadCert] on failure.
 func LoadCert(certDir, fullchainPem, privkeyPem string) (cert *tls.Certificate, absCertDir string, err error) {
+	return loadCert(certDir, fullchainPem, privkeyPem, false)
+}
+
+// LoadCertStrict is like [LoadCert], except that fullchainPem and privkeyPem
+// must resolve inside the certificate directory. A name that is not local or
+// leads outside through a symlink fails with an error matching both
+// [ErrLoadCert] and [ErrPathEscape]. The files are read through an [os.Root]
+// opened on the directory, so a symlink swapped in after the check cannot
+// escape either.
+func LoadCertStrict(certDir, fullchainPem, privkeyPem string) (cert *tls.Certificate, absCertDir string, err error) {
+	return loadCert(certDir, fullchainPem, privkeyPem, true)
+}
+
+func loadCert(certDir, fullchainPem, privkeyPem string, strict bool) (cert *tls.Certificate, absCertDir string, err error) {
+	// Only the caller's certDir is expanded; the directory systemd passes is
+	// used verbatim. Check for empty after expansion: a non-empty input may
+	// expand to empty (e.g. "$HOME" with HOME unset), and filepath.Abs("")
+	// would resolve to the current working directory.
+	if certDir == "" {
+		certDir = credentialsCertDir(fullchainPem)
+	} else {
+		certDir = os.ExpandEnv(certDir)
+	}
 	if certDir != "" {
-		// Re-check after expansion: a non-empty input may expand to empty
-		// (e.g. "$HOME" with HOME unset), and filepath.Abs("") would resolve
-		// to the current working directory rather than leaving certDir empty.
-		if certDir = os.ExpandEnv(certDir); certDir != "" {
-			if absCertDir, err = filepath.Abs(certDir); err == nil {
-				var cer tls.Certificate
-				if fullchainPem == "" {
-					fullchainPem = FullchainPem
-				}
-				if privkeyPem == "" {
-					privkeyPem = PrivkeyPem
-				}
+		if fullchainPem == "" {
+			fullchainPem = FullchainPem
+		}
+		if privkeyPem == "" {
+			privkeyPem = PrivkeyPem
+		}
+		if absCertDir, err = filepath.Abs(certDir); err == nil {
+			var cer tls.Certificate
+			if strict {
+				cer, err = loadX509KeyPairConfined(absCertDir, fullchainPem, privkeyPem)
+			} else {
 				fc := filepath.Join(absCertDir, fullchainPem)
 				pk := filepath.Join(absCertDir, privkeyPem)
-				if cer, err = tls.LoadX509KeyPair(fc, pk); err == nil {
-					cert = &cer
-				}
+				cer, err = loadX509KeyPair(os.ReadFile, fc, pk)
+			}
+			if err == nil {
+				cert = &cer
+			}
+			certDir = absCertDir
+		}
+		err = newErrLoadCert(certDir, fullchainPem, privkeyPem, err)
+	}
+	return
+}
+
+// loadX509KeyPairConfined loads a key pair from files that must stay inside
+// dir.
+func loadX509KeyPairConfined(dir, fullchainPem, privkeyPem string) (cer tls.Certificate, err error) {
+	if _, err = ConfinedPath(dir, fullchainPem); err == nil {
+		if _, err = ConfinedPath(dir, privkeyPem); err == nil {
+			var root *os.Root
+			if root, err = os.OpenRoot(dir); err == nil {
+				cer, err = loadX509KeyPair(root.ReadFile, fullchainPem, privkeyPem)
+				_ = root.Close()
+			}
+		}
+	}
+	return
+}
+
+// loadX509KeyPair reads fullchainPem and privkeyPem using readFile and parses
+// them with [crypto/tls.X509KeyPair]. Files without the expected PEM blocks
+// fail with errNoPEMData, and a key that does not belong to the first
+// certificate fails with errKeyMismatch.
+func loadX509KeyPair(readFile func(string) ([]byte, error), fullchainPem, privkeyPem string) (cer tls.Certificate, err error) {
+	var certPEM, keyPEM []byte
+	if certPEM, err = readFile(fullchainPem); err == nil {
+		if keyPEM, err = readFile(privkeyPem); err == nil {
+			certDER := findPEMBlock(certPEM, func(t string) bool { return t == "CERTIFICATE" })
+			keyDER := findPEMBlock(keyPEM, func(t string) bool { return t == "PRIVATE KEY" || strings.HasSuffix(t, " PRIVATE KEY") })
+			switch {
+			case certDER == nil:
+				err = fmt.Errorf("%s: %w", fullchainPem, errNoPEMData)
+			case keyDER == nil:
+				err = fmt.Errorf("%s: %w", privkeyPem, errNoPEMData)
+			case !publicKeysMatch(certDER, keyDER):
+				err = fmt.Errorf("%s, %s: %w", fullchainPem, privkeyPem, errKeyMismatch)
+			default:
+				cer, err = tls.X509KeyPair(certPEM, keyPEM)
 			}
 		}
 	}
 	return
 }
+
+// findPEMBlock returns the bytes of the first PEM block in data whose type
+// satisfies match, or nil if there is none.
+func findPEMBlock(data []byte, match func(string) bool) (der []byte) {
+	for der == nil {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			break
+		}
+		if match(block.Type) {
+			der = block.Bytes
+		}
+	}
+	return
+}
+
+// publicKeysMatch reports whether the private key in keyDER belongs to the
+// certificate in certDER. If either fails to parse it reports true, leaving
+// the parse error to [crypto/tls.X509KeyPair].
+func publicKeysMatch(certDER, keyDER []byte) (yes bool) {
+	yes = true
+	if leaf, err := x509.ParseCertificate(certDER); err == nil {
+		if key, ok := parsePrivateKey(keyDER).(crypto.Signer); ok {
+			if pub, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); ok {
+				yes = pub.Equal(key.Public())
+			}
+		}
+	}
+	return
+}
+
+// parsePrivateKey parses der in the formats [crypto/tls.X509KeyPair] accepts,
+// returning nil if none of them fit.
+func parsePrivateKey(der []byte) (key any) {
+	var err error
+	if key, err = x509.ParsePKCS1PrivateKey(der); err != nil {
+		if key, err = x509.ParsePKCS8PrivateKey(der); err != nil {
+			if key, err = x509.ParseECPrivateKey(der); err != nil {
+				key = nil
+			}
+		}
+	}
+	return
+}
+
+// credentialsCertDir returns $CREDENTIALS_DIRECTORY if it contains
+// fullchainPem (or [FullchainPem] if empty), otherwise an empty string.
+func credentialsCertDir(fullchainPem string) (dir string) {
+	if dir = os.Getenv("CREDENTIALS_DIRECTORY"); dir != "" {
+		if fullchainPem == "" {
+			fullchainPem = FullchainPem
+		}
+		if _, err := os.Stat(filepath.Join(dir, fullchainPem)); err != nil {
+			dir = ""
+		}
+	}
+	return
+}
@@ -1,7 +1,17 @@
 package webserv_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/linkdata/webserv"
@@ -67,3 +77,147 @@ func TestLoadCert(t *testing.T) {
 		}
 	})
 }
+
+func TestLoadCert_ErrorMatchesErrLoadCert(t *testing.T) {
+	dir := t.TempDir()
+	cert, absDir, err := webserv.LoadCert(dir, "", "")
+	if err == nil {
+		t.Fatal("expected error for missing cert files")
+	}
+	if cert != nil {
+		t.Error("cert not nil")
+	}
+	if absDir != dir {
+		t.Errorf("absCertDir = %q, want %q", absDir, dir)
+	}
+	if !errors.Is(err, webserv.ErrLoadCert) {
+		t.Errorf("expected errors.Is(err, ErrLoadCert), got: %v", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected errors.Is(err, fs.ErrNotExist), got: %v", err)
+	}
+	var lce webserv.LoadCertError
+	if !errors.As(err, &lce) {
+		t.Fatalf("expected errors.As(err, LoadCertError), got: %T", err)
+	}
+	if lce.CertDir != dir || lce.FullchainPem != webserv.FullchainPem || lce.PrivkeyPem != webserv.PrivkeyPem {
+		t.Errorf("LoadCertError = %#v", lce)
+	}
+	if !strings.HasPrefix(err.Error(), "LoadCert") {
+		t.Error("missing prefix")
+	}
+}
+
+func TestLoadCert_HintsAtMismatchedOrMissingPEM(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	withCertFiles(t, func(destdir string) {
+		if err := os.WriteFile(filepath.Join(destdir, "other.pem"), otherKey, 0o640); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(destdir, "empty.pem"), []byte("not pem\n"), 0o640); err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range []struct {
+			fullchain, privkey, want string
+		}{
+			{webserv.FullchainPem, "other.pem", "does not belong"},
+			{"empty.pem", webserv.PrivkeyPem, "PEM"},
+			{webserv.FullchainPem, "empty.pem", "PEM"},
+		} {
+			for _, load := range []func(string, string, string) (*tls.Certificate, string, error){webserv.LoadCert, webserv.LoadCertStrict} {
+				_, _, err := load(destdir, tc.fullchain, tc.privkey)
+				var lce webserv.LoadCertError
+				if !errors.As(err, &lce) || !strings.Contains(lce.Hint, tc.want) {
+					t.Errorf("LoadCert(%q, %q) = %v, want a hint containing %q", tc.fullchain, tc.privkey, err, tc.want)
+				}
+			}
+		}
+	})
+}
+
+func TestLoadCert_UsesSystemdCredentialsDirectory(t *testing.T) {
+	withCertFiles(t, func(destdir string) {
+		t.Setenv("CREDENTIALS_DIRECTORY", destdir)
+		cert, absCertDir, err := webserv.LoadCert("", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cert == nil || absCertDir != destdir {
+			t.Fatalf("LoadCert(\"\") = (%v, %q), want certificate from %q", cert, absCertDir, destdir)
+		}
+	})
+	t.Setenv("CREDENTIALS_DIRECTORY", t.TempDir())
+	if cert, absCertDir, err := webserv.LoadCert("", "", ""); cert != nil || absCertDir != "" || err != nil {
+		t.Fatalf("LoadCert(\"\") without credentials = (%v, %q, %v), want nothing loaded", cert, absCertDir, err)
+	}
+}
+
+func TestLoadCert_SystemdCredentialsDirectoryIsNotExpanded(t *testing.T) {
+	withCertFiles(t, func(destdir string) {
+		credDir := filepath.Join(t.TempDir(), "cred$HOME")
+		if err := os.Symlink(destdir, credDir); err != nil {
+			t.Skip(err)
+		}
+		t.Setenv("CREDENTIALS_DIRECTORY", credDir)
+		if cert, absCertDir, err := webserv.LoadCert("", "", ""); err != nil || cert == nil || absCertDir != credDir {
+			t.Fatalf("LoadCert(\"\") = (%v, %q, %v), want certificate from %q verbatim", cert, absCertDir, err, credDir)
+		}
+	})
+}
+
+func TestLoadCertStrict_RejectsEscapingNames(t *testing.T) {
+	withCertFiles(t, func(destdir string) {
+		if cert, _, err := webserv.LoadCertStrict(destdir, "", ""); err != nil || cert == nil {
+			t.Fatalf("LoadCertStrict() = (%v, %v), want certificate", cert, err)
+		}
+		sub := filepath.Join(destdir, "sub")
+		if err := os.Mkdir(sub, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		names := []string{filepath.Join("..", webserv.FullchainPem)}
+		if os.Symlink(filepath.Join(destdir, webserv.FullchainPem), filepath.Join(sub, "link.pem")) == nil {
+			names = append(names, "link.pem")
+		}
+		for _, name := range names {
+			cert, _, err := webserv.LoadCertStrict(sub, name, filepath.Join("..", webserv.PrivkeyPem))
+			if cert != nil || !errors.Is(err, webserv.ErrLoadCert) || !errors.Is(err, webserv.ErrPathEscape) {
+				t.Errorf("LoadCertStrict(%q) = (%v, %v), want match %v and %v", name, cert, err, webserv.ErrLoadCert, webserv.ErrPathEscape)
+			}
+		}
+		// The default loader keeps following such paths.
+		if cert, _, err := webserv.LoadCert(sub, filepath.Join("..", webserv.FullchainPem), filepath.Join("..", webserv.PrivkeyPem)); err != nil || cert == nil {
+			t.Fatalf("LoadCert() = (%v, %v), want certificate", cert, err)
+		}
+	})
+}
+
+func TestConfigListen_StrictPathsConfinesCertFiles(t *testing.T) {
+	withCertFiles(t, func(destdir string) {
+		sub := filepath.Join(destdir, "sub")
+		if err := os.Mkdir(sub, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &webserv.Config{
+			Address:      "127.0.0.1:0",
+			CertDir:      sub,
+			FullchainPem: filepath.Join("..", webserv.FullchainPem),
+			PrivkeyPem:   filepath.Join("..", webserv.PrivkeyPem),
+			StrictPaths:  true,
+		}
+		l, err := cfg.Listen()
+		if l != nil {
+			_ = l.Close()
+		}
+		if !errors.Is(err, webserv.ErrLoadCert) || !errors.Is(err, webserv.ErrPathEscape) {
+			t.Fatalf("Listen() = %v, want match %v and %v", err, webserv.ErrLoadCert, webserv.ErrPathEscape)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package webserv
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	errLocked    = errors.New("held by another instance")
+	errNoDataDir = errors.New("requires a data directory")
+)
+
+func pidBytes() []byte {
+	return []byte(strconv.Itoa(os.Getpid()) + "\n")
+}
+
+// readPID returns the process ID written to f, or zero if there is none.
+func readPID(f *os.File) (pid int) {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid, _ = strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	return
+}
+
+// AcquireLock opens or creates the lock file at path, takes an exclusive
+// non-blocking flock on it and writes the process ID into it.
+//
+// The lock is held until the returned file is closed or the process exits.
+// The lock file itself is never removed, since removing it would let another
+// process lock a new file while this one still holds the old one.
+//
+// If another process holds the lock, the error wraps a message naming it and
+// [LockError.PID] holds its process ID if it could be read from the file.
+// Not supported on Windows, Solaris or AIX.
+//
+// Returns an error matching [ErrLock] on failure.
+func AcquireLock(path string) (f *os.File, err error) {
+	return acquireLock(path, func(flag int, perm fs.FileMode) (*os.File, error) {
+		return os.OpenFile(path, flag, perm)
+	})
+}
+
+// acquireLock is [AcquireLock], opening the lock file at path with openFile.
+func acquireLock(path string, openFile func(flag int, perm fs.FileMode) (*os.File, error)) (f *os.File, err error) {
+	var pid int
+	if f, err = openFile(os.O_RDWR|os.O_CREATE, 0o644); err == nil {
+		if err = tryLock(f); err == nil {
+			if err = f.Truncate(0); err == nil {
+				_, err = f.WriteAt(pidBytes(), 0)
+			}
+		} else if errors.Is(err, errLocked) {
+			pid = readPID(f)
+		}
+		if err != nil {
+			_ = f.Close()
+			f = nil
+		}
+	}
+	err = newErrLock(path, pid, err)
+	return
+}
+
+// dataDirFile returns the path of the file name inside cfg.DataDir, failing
+// with an error matching [ErrPathEscape] if name is not local. With
+// cfg.StrictPaths, name must not lead outside through a symlink either.
+func (cfg *Config) dataDirFile(name string) (path string, err error) {
+	if cfg.StrictPaths {
+		path, err = ConfinedPath(cfg.DataDir, name)
+	} else if path = filepath.Join(cfg.DataDir, name); !filepath.IsLocal(name) {
+		err = PathEscapeError{Base: cfg.DataDir, Path: name}
+	}
+	return
+}
+
+// openDataDirFile opens the file name inside cfg.DataDir, found at path.
+// With cfg.StrictPaths it is opened through inst.dataRoot, so that a symlink
+// swapped in after dataDirFile checked it cannot lead outside either.
+func (inst *instance) openDataDirFile(path, name string, flag int, perm fs.FileMode) (*os.File, error) {
+	if inst.dataRoot != nil {
+		return inst.dataRoot.OpenFile(name, flag, perm)
+	}
+	return os.OpenFile(path, flag, perm)
+}
+
+// removeDataDirFile removes the file name inside cfg.DataDir, found at path.
+func (inst *instance) removeDataDirFile(path, name string) error {
+	if inst.dataRoot != nil {
+		return inst.dataRoot.Remove(name)
+	}
+	return os.Remove(path)
+}
+
+// lockDataDir takes the cfg.LockFile lock and writes cfg.PIDFile, both
+// inside cfg.DataDir.
+func (cfg *Config) lockDataDir() (err error) {
+	inst := cfg.instance()
+	if cfg.StrictPaths && cfg.DataDir != "" && (cfg.LockFile != "" || cfg.PIDFile != "") {
+		inst.dataRoot, err = os.OpenRoot(cfg.DataDir)
+		err = newErrDataDir(cfg.DataDir, err)
+	}
+	if err == nil && cfg.LockFile != "" {
+		path := cfg.LockFile
+		if cfg.DataDir == "" {
+			err = newErrLock(path, 0, errNoDataDir)
+		} else if path, err = cfg.dataDirFile(cfg.LockFile); err != nil {
+			err = newErrLock(cfg.LockFile, 0, err)
+		} else if inst.lock, err = acquireLock(path, func(flag int, perm fs.FileMode) (*os.File, error) {
+			return inst.openDataDirFile(path, cfg.LockFile, flag, perm)
+		}); err == nil {
+			cfg.logInfo("locked", "file", path)
+		}
+	}
+	if err == nil && cfg.PIDFile != "" {
+		path := cfg.PIDFile
+		if cfg.DataDir == "" {
+			err = errNoDataDir
+		} else if path, err = cfg.dataDirFile(cfg.PIDFile); err != nil {
+			path = cfg.PIDFile
+		} else {
+			var f *os.File
+			if f, err = inst.openDataDirFile(path, cfg.PIDFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644); err == nil {
+				_, err = f.Write(pidBytes())
+				if closeErr := f.Close(); err == nil {
+					err = closeErr
+				}
+				if err == nil {
+					inst.pidFile = path
+				}
+			}
+		}
+		err = newErrDataDir(path, err)
+	}
+	return
+}
+
+// unlockDataDir removes the PID file and releases the lock taken by
+// lockDataDir, if any.
+func (cfg *Config) unlockDataDir() {
+	inst := cfg.instance()
+	if inst.pidFile != "" {
+		if err := inst.removeDataDirFile(inst.pidFile, cfg.PIDFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			cfg.logWarn("removing PID file", "file", inst.pidFile, "err", err)
+		}
+		inst.pidFile = ""
+	}
+	if inst.lock != nil {
+		_ = inst.lock.Close()
+		inst.lock = nil
+	}
+	if inst.dataRoot != nil {
+		_ = inst.dataRoot.Close()
+		inst.dataRoot = nil
+	}
+}
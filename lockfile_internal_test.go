@@ -0,0 +1,30 @@
+package webserv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestInstanceOpenDataDirFile_RootRefusesSwappedSymlink covers a symlink
+// swapped in after dataDirFile checked the name.
+func TestInstanceOpenDataDirFile_RootRefusesSwappedSymlink(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	path := filepath.Join(dir, "pid")
+	if err := os.Symlink(filepath.Join(outside, "victim"), path); err != nil {
+		t.Skip("symlink:", err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+	inst := &instance{dataRoot: root}
+	if f, err := inst.openDataDirFile(path, "pid", os.O_WRONLY|os.O_CREATE, 0o644); err == nil {
+		_ = f.Close()
+		t.Fatal("openDataDirFile() followed a symlink out of the data directory")
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Fatalf("openDataDirFile() created %v outside the data directory", entries)
+	}
+}
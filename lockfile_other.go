@@ -0,0 +1,12 @@
+//go:build !(unix || linux) || aix || solaris
+
+package webserv
+
+import (
+	"errors"
+	"os"
+)
+
+func tryLock(*os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,21 @@
+//go:build !(unix || linux) || aix || solaris
+
+package webserv_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestAcquireLock_UnsupportedOS(t *testing.T) {
+	f, err := webserv.AcquireLock(filepath.Join(t.TempDir(), "lock"))
+	if f != nil {
+		_ = f.Close()
+	}
+	if !errors.Is(err, webserv.ErrLock) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("error %v does not match webserv.ErrLock and errors.ErrUnsupported", err)
+	}
+}
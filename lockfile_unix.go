@@ -0,0 +1,18 @@
+//go:build (unix || linux) && !(aix || solaris)
+
+package webserv
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+var flockFn = syscall.Flock
+
+func tryLock(f *os.File) (err error) {
+	if err = flockFn(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); errors.Is(err, syscall.EWOULDBLOCK) {
+		err = errLocked
+	}
+	return
+}
@@ -0,0 +1,140 @@
+//go:build (unix || linux) && !(aix || solaris)
+
+package webserv_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestAcquireLock_SecondLockNamesHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	f, err := webserv.AcquireLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(path); err != nil || strings.TrimSpace(string(b)) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("lock file contains %q (%v), want our pid", b, err)
+	}
+
+	f2, err := webserv.AcquireLock(path)
+	if f2 != nil {
+		_ = f2.Close()
+		t.Fatal("second AcquireLock succeeded")
+	}
+	var le webserv.LockError
+	if !errors.Is(err, webserv.ErrLock) || !errors.As(err, &le) {
+		t.Fatalf("AcquireLock() = %v, want LockError", err)
+	}
+	if le.Path != path || le.PID != os.Getpid() {
+		t.Fatalf("LockError = %+v, want path %q and pid %d", le, path, os.Getpid())
+	}
+	if want := "by pid " + strconv.Itoa(os.Getpid()); !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not contain %q", err, want)
+	}
+
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = webserv.AcquireLock(path); err != nil {
+		t.Fatalf("AcquireLock() after release = %v", err)
+	}
+	_ = f.Close()
+}
+
+func TestConfigListen_LockFileHeldUntilServeWithReturns(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &webserv.Config{Address: "127.0.0.1:0", DataDir: dir, LockFile: "lock", PIDFile: "pid"}
+	l, err := cfg.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pidFile := filepath.Join(dir, "pid")
+	if b, err := os.ReadFile(pidFile); err != nil || strings.TrimSpace(string(b)) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("PID file contains %q (%v), want our pid", b, err)
+	}
+
+	other := &webserv.Config{Address: "127.0.0.1:0", DataDir: dir, LockFile: "lock"}
+	l2, err := other.Listen()
+	if l2 != nil {
+		_ = l2.Close()
+		t.Fatal("second Listen succeeded")
+	}
+	var le webserv.LockError
+	if !errors.As(err, &le) || le.PID != os.Getpid() {
+		t.Fatalf("second Listen() = %v, want LockError naming pid %d", err, os.Getpid())
+	}
+	if other.DataDir != "" {
+		t.Errorf("DataDir = %q after failed Listen, want empty", other.DataDir)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = cfg.ServeWith(ctx, &http.Server{}, l); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ServeWith() = %v, want %v", err, context.Canceled)
+	}
+	if _, err = os.Stat(pidFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("PID file still present after ServeWith: %v", err)
+	}
+	other.DataDir = dir
+	if l2, err = other.Listen(); err != nil {
+		t.Fatalf("Listen() after ServeWith = %v", err)
+	}
+	_ = l2.Close()
+}
+
+func TestConfigListen_LockFileWithoutDataDirFails(t *testing.T) {
+	cfg := &webserv.Config{Address: "127.0.0.1:0", LockFile: "lock"}
+	l, err := cfg.Listen()
+	if l != nil {
+		_ = l.Close()
+	}
+	if !errors.Is(err, webserv.ErrLock) {
+		t.Fatalf("Listen() = %v, want match %v", err, webserv.ErrLock)
+	}
+}
+
+func TestConfigListen_LockAndPIDFileMustStayInDataDir(t *testing.T) {
+	outside := t.TempDir()
+	for _, tc := range []struct {
+		name   string
+		cfg    webserv.Config
+		target error
+	}{
+		{"lock file parent", webserv.Config{LockFile: "../lock"}, webserv.ErrLock},
+		{"absolute PID file", webserv.Config{PIDFile: filepath.Join(outside, "pid")}, webserv.ErrDataDir},
+		{"strict PID file through symlink", webserv.Config{PIDFile: "link/pid", StrictPaths: true}, webserv.ErrDataDir},
+		{"strict PID file dangling symlink", webserv.Config{PIDFile: "dangling", StrictPaths: true}, webserv.ErrDataDir},
+		{"strict lock file dangling symlink", webserv.Config{LockFile: "dangling", StrictPaths: true}, webserv.ErrLock},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.Address = "127.0.0.1:0"
+			cfg.DataDir = t.TempDir()
+			if err := os.Symlink(outside, filepath.Join(cfg.DataDir, "link")); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(filepath.Join(outside, "victim"), filepath.Join(cfg.DataDir, "dangling")); err != nil {
+				t.Fatal(err)
+			}
+			l, err := cfg.Listen()
+			if l != nil {
+				_ = l.Close()
+			}
+			if !errors.Is(err, tc.target) || !errors.Is(err, webserv.ErrPathEscape) {
+				t.Fatalf("Listen() = %v, want match %v and %v", err, tc.target, webserv.ErrPathEscape)
+			}
+			if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+				t.Fatalf("Listen() wrote %v outside the data directory", entries)
+			}
+		})
+	}
+}
@@ -6,3 +6,10 @@ type Logger interface {
 	Warn(msg string, keyValuePairs ...any)
 	Error(msg string, keyValuePairs ...any)
 }
+
+// Reopener is implemented by loggers that write to files and can reopen them,
+// for example after the files were rotated. [Config.Reload] calls Reopen if
+// [Config.Logger] implements it.
+type Reopener interface {
+	Reopen() error
+}
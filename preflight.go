@@ -0,0 +1,107 @@
+package webserv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var errLowDiskSpace = errors.New("filesystem is low on free space")
+
+// ProbeDataDir verifies that the current user can write to dataDir by
+// creating a temporary file in it, writing and syncing a byte, and removing
+// it again. This catches read-only mounts and full filesystems up front.
+// Does nothing if dataDir is empty.
+//
+// Returns an error matching [ErrDataDir] on failure.
+func ProbeDataDir(dataDir string) (err error) {
+	if dataDir != "" {
+		var f *os.File
+		if f, err = os.CreateTemp(dataDir, ".webserv-probe-*"); err == nil {
+			if _, err = f.Write([]byte{0}); err == nil {
+				err = f.Sync()
+			}
+			err = errors.Join(err, f.Close(), os.Remove(f.Name()))
+		}
+		err = newErrDataDir(dataDir, err)
+	}
+	return
+}
+
+// CheckDataDirSpace verifies that the filesystem holding dataDir has at least
+// minFreeBytes of space available to unprivileged users and minFreeInodes
+// free inodes, counting any the filesystem reserves for root. A zero minimum
+// is not checked. Does nothing if dataDir is empty or
+// both minimums are zero.
+//
+// Only supported on Linux, macOS, FreeBSD and DragonFly; elsewhere it returns
+// an error matching [errors.ErrUnsupported].
+//
+// Returns an error matching [ErrDataDir] on failure.
+func CheckDataDirSpace(dataDir string, minFreeBytes, minFreeInodes uint64) (err error) {
+	if dataDir != "" && (minFreeBytes != 0 || minFreeInodes != 0) {
+		var freeBytes, freeInodes uint64
+		if freeBytes, freeInodes, err = diskFree(dataDir); err == nil {
+			if freeBytes < minFreeBytes {
+				err = fmt.Errorf("%w: %d bytes available, want at least %d", errLowDiskSpace, freeBytes, minFreeBytes)
+			} else if freeInodes < minFreeInodes {
+				err = fmt.Errorf("%w: %d inodes available, want at least %d", errLowDiskSpace, freeInodes, minFreeInodes)
+			}
+		}
+		err = newErrDataDir(dataDir, err)
+	}
+	return
+}
+
+// preflightDataDir runs the cfg.DataDirProbe and free space checks on
+// cfg.DataDir.
+func (cfg *Config) preflightDataDir() (err error) {
+	if cfg.DataDirProbe {
+		err = ProbeDataDir(cfg.DataDir)
+	}
+	if err == nil {
+		err = CheckDataDirSpace(cfg.DataDir, cfg.DataDirMinFreeBytes, cfg.DataDirMinFreeInodes)
+	}
+	return
+}
+
+// monitorDataDir repeats preflightDataDir every cfg.DataDirMonitor until the
+// returned function is called, logging a warning when the checks start failing
+// and an info message when they pass again.
+func (cfg *Config) monitorDataDir() (stop func()) {
+	stop = func() {}
+	if cfg.DataDirMonitor > 0 && cfg.DataDir != "" {
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(cfg.DataDirMonitor)
+			defer ticker.Stop()
+			failing := false
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if err := cfg.preflightDataDir(); err != nil {
+						if !failing {
+							cfg.logWarn("data directory check failed", "dir", cfg.DataDir, "err", err)
+						}
+						failing = true
+					} else if failing {
+						cfg.logInfo("data directory check passed", "dir", cfg.DataDir)
+						failing = false
+					}
+				}
+			}
+		}()
+		stop = func() {
+			close(done)
+			wg.Wait()
+		}
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package webserv
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProbeDataDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := ProbeDataDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Fatalf("ProbeDataDir left %v behind (%v)", entries, err)
+	}
+	if err := ProbeDataDir(""); err != nil {
+		t.Fatalf("ProbeDataDir(\"\") = %v, want nil", err)
+	}
+	if err := ProbeDataDir(filepath.Join(dir, "missing")); !errors.Is(err, ErrDataDir) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("ProbeDataDir(missing) = %v, want match %v and %v", err, ErrDataDir, os.ErrNotExist)
+	}
+}
+
+func TestCheckDataDirSpace(t *testing.T) {
+	dir := t.TempDir()
+	if err := CheckDataDirSpace(dir, 1, 0); errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckDataDirSpace(dir, 0, 0); err != nil {
+		t.Fatalf("CheckDataDirSpace() without minimums = %v, want nil", err)
+	}
+	for _, tc := range []struct{ bytes, inodes uint64 }{{math.MaxUint64, 0}, {0, math.MaxUint64}} {
+		err := CheckDataDirSpace(dir, tc.bytes, tc.inodes)
+		if !errors.Is(err, ErrDataDir) || !errors.Is(err, errLowDiskSpace) {
+			t.Errorf("CheckDataDirSpace(%d, %d) = %v, want match %v and %v", tc.bytes, tc.inodes, err, ErrDataDir, errLowDiskSpace)
+		}
+	}
+}
+
+func TestConfigListen_DataDirMinFreeBytesFails(t *testing.T) {
+	cfg := &Config{Address: "127.0.0.1:0", DataDir: t.TempDir(), DataDirProbe: true, DataDirMinFreeBytes: math.MaxUint64}
+	l, err := cfg.Listen()
+	if l != nil {
+		_ = l.Close()
+		t.Fatal("Listen() returned a listener")
+	}
+	if !errors.Is(err, ErrDataDir) {
+		t.Fatalf("Listen() = %v, want match %v", err, ErrDataDir)
+	}
+}
+
+func TestConfigMonitorDataDir_WarnsOnceWhileFailing(t *testing.T) {
+	rl := &recordingLogger{}
+	cfg := &Config{DataDir: t.TempDir(), DataDirMonitor: time.Millisecond, DataDirMinFreeBytes: math.MaxUint64, Logger: rl}
+	stop := cfg.monitorDataDir()
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	if len(rl.warnings) != 1 || rl.warnings[0] != "webserv: data directory check failed" {
+		t.Fatalf("warnings = %v, want one check failure", rl.warnings)
+	}
+}
+
+func TestConfigMonitorDataDir_DisabledIsNoop(t *testing.T) {
+	cfg := &Config{DataDir: t.TempDir()}
+	cfg.monitorDataDir()()
+}
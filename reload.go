@@ -0,0 +1,89 @@
+package webserv
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Reload reloads the certificates served by the listener from cfg.CertDir,
+// reopens cfg.Logger if it implements [Reopener], and calls cfg.OnReload
+// with ctx, in that order. All steps are attempted; their errors are joined.
+// Serving is not interrupted, and if the certificates cannot be loaded the
+// previous ones stay in use.
+//
+// The certificates are read again under the restrictions [Config.Listen] has
+// applied since loading them: as cfg.User, inside cfg.Chroot, and within what
+// cfg.Landlock allows. For Reload to work, the new user must be able to read
+// the certificate files at their paths inside the chroot. A root-only private
+// key, or a cfg.CertDir outside cfg.Chroot, makes every reload fail. Landlock
+// grants read access beneath cfg.CertDir and to the certificate files. When
+// loading fails for lack of access, the log names the restrictions in effect.
+//
+// On Unix, [Config.ServeWith] calls Reload on SIGHUP. Reload may also be called
+// directly, concurrently with serving.
+func (cfg *Config) Reload(ctx context.Context) (err error) {
+	var hint string
+	if certs := cfg.instance().certs; certs != nil && certs.Load() != nil {
+		var cert *tls.Certificate
+		if cert, _, err = loadCert(cfg.CertDir, cfg.FullchainPem, cfg.PrivkeyPem, cfg.StrictPaths); err == nil && cert != nil {
+			certs.Store(cert)
+		}
+		hint = cfg.certReloadHint(err)
+	}
+	if r, ok := cfg.Logger.(Reopener); ok {
+		err = errors.Join(err, r.Reopen())
+	}
+	if cfg.OnReload != nil {
+		err = errors.Join(err, cfg.OnReload(ctx))
+	}
+	if err == nil {
+		cfg.logInfo("reloaded")
+	} else if hint != "" {
+		cfg.logError("reload failed", "err", err, "hint", hint)
+	} else {
+		cfg.logError("reload failed", "err", err)
+	}
+	return
+}
+
+// certReloadHint names the restrictions applied by Listen that can keep
+// Reload from reading the certificates, if err is a failure to access them.
+func (cfg *Config) certReloadHint(err error) (hint string) {
+	var constraints []string
+	if errors.Is(err, fs.ErrNotExist) && cfg.Chroot != "" {
+		constraints = append(constraints, "chroot to "+cfg.Chroot)
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		if cfg.User != "" {
+			constraints = append(constraints, "user "+cfg.User)
+		}
+		if cfg.Landlock {
+			constraints = append(constraints, "landlock")
+		}
+	}
+	if len(constraints) > 0 {
+		hint = "certificates not accessible after " + strings.Join(constraints, " and ")
+	}
+	return
+}
+
+// certReadPaths returns the paths Reload reads the certificates from:
+// cfg.CertDir, and the certificate files themselves if they lie outside it.
+func (cfg *Config) certReadPaths() (paths []string) {
+	if cfg.CertDir != "" {
+		paths = append(paths, cfg.CertDir)
+		for _, name := range []string{cfg.FullchainPem, cfg.PrivkeyPem} {
+			if name != "" {
+				path := filepath.Join(cfg.CertDir, name)
+				if rel, err := filepath.Rel(cfg.CertDir, path); err != nil || !filepath.IsLocal(rel) {
+					paths = append(paths, path)
+				}
+			}
+		}
+	}
+	return
+}
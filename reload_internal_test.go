@@ -0,0 +1,40 @@
+package webserv
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestConfigCertReadPaths(t *testing.T) {
+	certDir := filepath.Join(string(filepath.Separator), "certs")
+	cfg := &Config{}
+	if got := cfg.certReadPaths(); len(got) != 0 {
+		t.Fatalf("certReadPaths() = %q without CertDir, want none", got)
+	}
+	cfg = &Config{CertDir: certDir, FullchainPem: "chain.pem", PrivkeyPem: filepath.Join("..", "keys", "key.pem")}
+	want := []string{certDir, filepath.Join(string(filepath.Separator), "keys", "key.pem")}
+	if got := cfg.certReadPaths(); !slices.Equal(got, want) {
+		t.Fatalf("certReadPaths() = %q, want %q", got, want)
+	}
+}
+
+func TestConfigCertReloadHint(t *testing.T) {
+	for _, tc := range []struct {
+		cfg  Config
+		err  error
+		want string
+	}{
+		{Config{User: "www-data", Landlock: true}, fmt.Errorf("open: %w", fs.ErrPermission), "certificates not accessible after user www-data and landlock"},
+		{Config{Chroot: "/srv/jail"}, &fs.PathError{Op: "open", Path: "/certs/privkey.pem", Err: os.ErrNotExist}, "certificates not accessible after chroot to /srv/jail"},
+		{Config{User: "www-data"}, fs.ErrNotExist, ""},
+		{Config{User: "www-data", Chroot: "/srv/jail"}, nil, ""},
+	} {
+		if got := tc.cfg.certReloadHint(tc.err); got != tc.want {
+			t.Errorf("certReloadHint(%v) with %+v = %q, want %q", tc.err, tc.cfg, got, tc.want)
+		}
+	}
+}
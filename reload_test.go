@@ -0,0 +1,173 @@
+package webserv_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linkdata/webserv"
+)
+
+type reopenLogger struct {
+	mu      sync.Mutex
+	reopens int
+	errors  []string
+}
+
+func (l *reopenLogger) Info(string, ...any) {}
+func (l *reopenLogger) Warn(string, ...any) {}
+func (l *reopenLogger) Error(msg string, _ ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, msg)
+}
+func (l *reopenLogger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reopens++
+	return nil
+}
+
+// writeNewCert replaces the key pair in dir with a fresh self-signed one and
+// returns its serial number.
+func writeNewCert(t *testing.T, dir string) *big.Int {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"webserv test"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, webserv.FullchainPem), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, webserv.PrivkeyPem), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	return serial
+}
+
+func servedSerial(t *testing.T, addr net.Addr) *big.Int {
+	t.Helper()
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr.String(), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber
+}
+
+// serveInBackground serves l with cfg until the test ends.
+func serveInBackground(t *testing.T, cfg *webserv.Config, l net.Listener) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, &http.Server{}, l) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestConfigReload_ReplacesCertificateAndCallsHooks(t *testing.T) {
+	withCertFiles(t, func(destdir string) {
+		first := writeNewCert(t, destdir)
+		logger := &reopenLogger{}
+		var reloads int
+		cfg := &webserv.Config{
+			Address: "127.0.0.1:0",
+			CertDir: destdir,
+			Logger:  logger,
+			OnReload: func(ctx context.Context) error {
+				reloads++
+				return nil
+			},
+		}
+		l, err := cfg.Listen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		serveInBackground(t, cfg, l)
+		if got := servedSerial(t, l.Addr()); got.Cmp(first) != 0 {
+			t.Fatalf("served serial %v, want %v", got, first)
+		}
+
+		second := writeNewCert(t, destdir)
+		if err = cfg.Reload(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		if got := servedSerial(t, l.Addr()); got.Cmp(second) != 0 {
+			t.Fatalf("served serial %v after reload, want %v", got, second)
+		}
+		if reloads != 1 || logger.reopens != 1 {
+			t.Fatalf("OnReload called %d times and Reopen %d times, want 1 each", reloads, logger.reopens)
+		}
+	})
+}
+
+func TestConfigReload_FailureKeepsCertificate(t *testing.T) {
+	withCertFiles(t, func(destdir string) {
+		first := writeNewCert(t, destdir)
+		logger := &reopenLogger{}
+		hookErr := errors.New("hook failed")
+		cfg := &webserv.Config{
+			Address:  "127.0.0.1:0",
+			CertDir:  destdir,
+			Logger:   logger,
+			OnReload: func(context.Context) error { return hookErr },
+		}
+		l, err := cfg.Listen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		serveInBackground(t, cfg, l)
+
+		if err = os.WriteFile(filepath.Join(destdir, webserv.PrivkeyPem), []byte("garbage"), 0o640); err != nil {
+			t.Fatal(err)
+		}
+		err = cfg.Reload(t.Context())
+		if !errors.Is(err, webserv.ErrLoadCert) || !errors.Is(err, hookErr) {
+			t.Fatalf("Reload() = %v, want match %v and %v", err, webserv.ErrLoadCert, hookErr)
+		}
+		if logger.reopens != 1 {
+			t.Errorf("Reopen called %d times, want 1", logger.reopens)
+		}
+		if len(logger.errors) != 1 || logger.errors[0] != "webserv: reload failed" {
+			t.Errorf("errors logged = %v, want reload failure", logger.errors)
+		}
+		if got := servedSerial(t, l.Addr()); got.Cmp(first) != 0 {
+			t.Fatalf("served serial %v after failed reload, want %v", got, first)
+		}
+	})
+}
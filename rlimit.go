@@ -0,0 +1,34 @@
+package webserv
+
+import "fmt"
+
+// applyProcessLimits sets the umask and resource limits configured in cfg and
+// logs the effective values. It runs first in Listen, while still privileged,
+// so that hard limits can be raised.
+func (cfg *Config) applyProcessLimits() (err error) {
+	if cfg.Umask != 0 {
+		if err = setUmask(int(cfg.Umask.Perm())); err == nil {
+			cfg.logInfo("umask", "umask", fmt.Sprintf("%#o", cfg.Umask.Perm()))
+		}
+	}
+	if err == nil && cfg.MaxOpenFiles != 0 {
+		err = cfg.setRlimit("RLIMIT_NOFILE", rlimitNofile, cfg.MaxOpenFiles)
+	}
+	if err == nil && cfg.DisableCoreDumps {
+		err = cfg.setRlimit("RLIMIT_CORE", rlimitCore, 0)
+	}
+	return newErrResourceLimits(err)
+}
+
+func (cfg *Config) setRlimit(name string, resource int, limit uint64) (err error) {
+	if err = setRlimit(resource, limit); err == nil {
+		var cur, max uint64
+		if cur, max, err = getRlimit(resource); err == nil {
+			cfg.logInfo("resource limit", "resource", name, "soft", cur, "hard", max)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("%s=%d: %w", name, limit, err)
+	}
+	return
+}
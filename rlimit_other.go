@@ -0,0 +1,22 @@
+//go:build !(unix || linux)
+
+package webserv
+
+import "errors"
+
+const (
+	rlimitNofile = iota
+	rlimitCore
+)
+
+func setUmask(int) error {
+	return errors.ErrUnsupported
+}
+
+func getRlimit(int) (uint64, uint64, error) {
+	return 0, 0, errors.ErrUnsupported
+}
+
+func setRlimit(int, uint64) error {
+	return errors.ErrUnsupported
+}
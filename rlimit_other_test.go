@@ -0,0 +1,21 @@
+//go:build !(unix || linux)
+
+package webserv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfigApplyProcessLimits_UnsupportedOS(t *testing.T) {
+	cfg := &Config{MaxOpenFiles: 1024}
+	if err := cfg.applyProcessLimits(); !errors.Is(err, ErrResourceLimits) || !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("error %v does not match ErrResourceLimits and errors.ErrUnsupported", err)
+	} else if !strings.Contains(err.Error(), "RLIMIT_NOFILE=1024: ") {
+		t.Errorf("error %q does not name RLIMIT_NOFILE", err)
+	}
+	if err := (&Config{}).applyProcessLimits(); err != nil {
+		t.Errorf("applyProcessLimits() without limits = %v, want nil", err)
+	}
+}
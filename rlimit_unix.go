@@ -0,0 +1,55 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	rlimitNofile = syscall.RLIMIT_NOFILE
+	rlimitCore   = syscall.RLIMIT_CORE
+)
+
+// rlimitValue abstracts over the signed (FreeBSD, DragonFly) and unsigned
+// field types of [syscall.Rlimit].
+type rlimitValue interface{ ~int64 | ~uint64 }
+
+func setRlimitFields[T rlimitValue](cur, max *T, c, m uint64) {
+	*cur, *max = T(c), T(m)
+}
+
+func setUmask(mask int) error {
+	syscall.Umask(mask)
+	return nil
+}
+
+func getRlimit(resource int) (cur, max uint64, err error) {
+	var rl syscall.Rlimit
+	if err = syscall.Getrlimit(resource, &rl); err == nil {
+		cur, max = uint64(rl.Cur), uint64(rl.Max)
+	} else {
+		err = os.NewSyscallError("getrlimit", err)
+	}
+	return
+}
+
+// setRlimit sets the soft limit of resource to limit. The hard limit is raised
+// to limit if it is lower and otherwise left as is, except that a zero limit
+// also sets the hard limit to zero so it cannot be raised again.
+func setRlimit(resource int, limit uint64) (err error) {
+	var rl syscall.Rlimit
+	if err = syscall.Getrlimit(resource, &rl); err == nil {
+		hard := uint64(rl.Max)
+		if limit > hard || limit == 0 {
+			hard = limit
+		}
+		setRlimitFields(&rl.Cur, &rl.Max, limit, hard)
+		err = syscall.Setrlimit(resource, &rl)
+	}
+	if err != nil {
+		err = os.NewSyscallError("setrlimit", err)
+	}
+	return
+}
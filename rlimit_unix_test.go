@@ -0,0 +1,102 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestSetRlimit_NofileKeepsHardLimit(t *testing.T) {
+	cur, max, err := getRlimit(rlimitNofile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = setRlimit(rlimitNofile, cur) }()
+
+	want := cur / 2
+	if err = setRlimit(rlimitNofile, want); err != nil {
+		t.Fatal(err)
+	}
+	gotCur, gotMax, err := getRlimit(rlimitNofile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotCur != want || gotMax != max {
+		t.Fatalf("RLIMIT_NOFILE = (%d, %d), want (%d, %d)", gotCur, gotMax, want, max)
+	}
+}
+
+func TestConfigApplyProcessLimits_UmaskAndLogging(t *testing.T) {
+	cur, _, err := getRlimit(rlimitNofile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := syscall.Umask(0o022)
+	defer syscall.Umask(old)
+
+	rl := &recordingLogger{}
+	cfg := &Config{Umask: 0o027, MaxOpenFiles: cur, Logger: rl}
+	if err = cfg.applyProcessLimits(); err != nil {
+		t.Fatal(err)
+	}
+	if got := syscall.Umask(0o022); got != 0o027 {
+		t.Fatalf("umask = %#o, want %#o", got, 0o027)
+	}
+	if want := []string{"webserv: umask", "webserv: resource limit"}; !reflect.DeepEqual(rl.messages, want) {
+		t.Fatalf("messages = %v, want %v", rl.messages, want)
+	}
+}
+
+func TestConfigApplyProcessLimits_UnraisableHardLimitFails(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root may raise hard limits")
+	}
+	_, max, err := getRlimit(rlimitNofile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max == ^uint64(0) {
+		t.Skip("hard limit is unlimited")
+	}
+	cfg := &Config{MaxOpenFiles: max + 1}
+	if err = cfg.applyProcessLimits(); !errors.Is(err, ErrResourceLimits) || !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("applyProcessLimits() = %v, want match %v and %v", err, ErrResourceLimits, os.ErrPermission)
+	}
+	if want := fmt.Sprintf("RLIMIT_NOFILE=%d: ", max+1); !strings.Contains(err.Error(), want) {
+		t.Errorf("applyProcessLimits() = %q, want it to contain %q", err, want)
+	}
+}
+
+// TestConfigApplyProcessLimits_DisableCoreDumps runs in a child process since
+// a zero hard limit cannot be raised again.
+func TestConfigApplyProcessLimits_DisableCoreDumps(t *testing.T) {
+	if os.Getenv("WEBSERV_CORE_CHILD") == "1" {
+		cfg := &Config{DisableCoreDumps: true}
+		if err := cfg.applyProcessLimits(); err != nil {
+			t.Fatal(err)
+		}
+		cur, max, err := getRlimit(rlimitCore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cur != 0 || max != 0 {
+			t.Fatalf("RLIMIT_CORE = (%d, %d), want (0, 0)", cur, max)
+		}
+		if err = setRlimit(rlimitCore, 1<<20); err == nil && os.Geteuid() != 0 {
+			t.Fatal("RLIMIT_CORE raised again after DisableCoreDumps")
+		}
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestConfigApplyProcessLimits_DisableCoreDumps$")
+	cmd.Env = append(os.Environ(), "WEBSERV_CORE_CHILD=1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("child failed: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,183 @@
+package webserv
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+)
+
+// ServerListener pairs a server with the listener it serves, for
+// [Config.ServeGroup].
+type ServerListener struct {
+	Server   *http.Server
+	Listener net.Listener
+}
+
+// serveExit reports that the Serve call of group member index returned err.
+type serveExit struct {
+	index int
+	err   error
+}
+
+// joinErrors is like [errors.Join], but returns a single non-nil error as is.
+func joinErrors(errs ...error) (err error) {
+	var n int
+	for _, e := range errs {
+		if e != nil {
+			err = e
+			n++
+		}
+	}
+	if n > 1 {
+		err = errors.Join(errs...)
+	}
+	return
+}
+
+// ServeGroup is like [Config.ServeWith], but serves several servers, each on
+// its own listener, under one lifecycle. This suits a process running both a
+// public server and an admin server for metrics or profiling. The first pair
+// is expected to use the listener returned by [Config.Listen]; cfg.ListenURL
+// is logged only for it.
+//
+// One set of signal handlers is installed (see [Config.Signals]), [Config.Ready] and the lifecycle
+// callbacks cover the whole group, and a signal or ctx being canceled shuts
+// all the servers down together: [Config.DrainDelay] applies once, unless ctx
+// was canceled, and the servers and the connections registered with
+// [Config.TrackHijacked] share a single [Config.ShutdownTimeLimit]. If any
+// server's Serve returns while the others are still serving, the others are
+// shut down the same way.
+//
+// The errors of the individual servers are joined with [errors.Join]. Apart
+// from that, the returned error follows the rules of [Config.ServeWith]: an
+// error from a server that stopped before the shutdown is always included.
+//
+// Panics if ctx is nil, group is empty, or any Server or Listener is nil.
+// Panics from Serve are recovered and returned as an error matching
+// [ErrServePanic].
+func (cfg *Config) ServeGroup(ctx context.Context, group ...ServerListener) (err error) {
+	if ctx == nil {
+		panic("webserv: nil context.Context")
+	}
+	if len(group) == 0 {
+		panic("webserv: empty server group")
+	}
+	for _, member := range group {
+		if member.Server == nil {
+			panic("webserv: nil http.Server")
+		}
+		if member.Listener == nil {
+			panic("webserv: nil net.Listener")
+		}
+	}
+	inst := cfg.instance()
+	defer cfg.unlockDataDir()
+	defer cfg.monitorDataDir()()
+	exits := make(chan serveExit, len(group))
+	serveCtx, cancelServe := context.WithCancelCause(ctx)
+	defer cancelServe(nil)
+	actions := cfg.signalActions()
+	shutdownSig := make(chan os.Signal, 1)
+	otherSig := make(chan os.Signal, 1)
+	notifySignals(actions, shutdownSig, otherSig)
+	defer signal.Stop(shutdownSig)
+	defer signal.Stop(otherSig)
+	active := make([]*atomic.Int64, len(group))
+	for i, member := range group {
+		if !cfg.LogTLSErrors {
+			// Install the filter before serving so the single write to
+			// srv.ErrorLog happens-before any connection goroutine reads it.
+			installTLSErrorLogFilter(member.Server)
+		}
+		active[i] = trackConns(member.Server)
+	}
+	defer inst.hijacked.reset()
+	cfg.setState(StateServing, nil)
+	defer func() {
+		if err != nil && err != ctx.Err() {
+			cfg.setState(StateFailed, err)
+		} else {
+			cfg.setState(StateStopped, nil)
+		}
+	}()
+	for i, member := range group {
+		if i == 0 {
+			cfg.logInfo("listening on", "address", member.Listener.Addr(), "url", cfg.ListenURL)
+		} else {
+			cfg.logInfo("listening on", "address", member.Listener.Addr())
+		}
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					exits <- serveExit{index: i, err: newErrServePanic(p)}
+				}
+			}()
+			exits <- serveExit{index: i, err: member.Server.Serve(member.Listener)}
+		}()
+	}
+	var hookErr error
+	if hookErr = cfg.runHook(ctx, "OnListening", cfg.OnListening); hookErr != nil {
+		cancelServe(hookErr)
+	}
+	var early []error // errors from servers that stopped before the shutdown
+	for running := len(group); running > 0; {
+		select {
+		case exit := <-exits:
+			running--
+			if isCleanServerClosed(exit.err) {
+				exit.err = nil
+			}
+			early = append(early, exit.err)
+			if running == 0 {
+				err = joinErrors(early...)
+			} else if exit.err != nil {
+				cancelServe(exit.err)
+			} else {
+				cancelServe(http.ErrServerClosed)
+			}
+		case sig := <-shutdownSig:
+			cancelServe(errSignal(sig))
+		case sig := <-otherSig:
+			switch actions[sig] {
+			case SignalReload:
+				_ = cfg.Reload(serveCtx)
+			case SignalDump:
+				_, _ = cfg.Dump()
+			default:
+				cfg.logInfo("signal ignored", "signal", sig)
+			}
+		case <-serveCtx.Done():
+			err = ctx.Err()
+			reason := context.Cause(serveCtx)
+			signal.Stop(shutdownSig)
+			cfg.setState(StateDraining, nil)
+			cfg.logInfo("stopped", "reason", reason)
+			hookErr = errors.Join(hookErr, cfg.runHook(ctx, "OnShutdownStart", cfg.OnShutdownStart))
+			// The caller canceling ctx wants serving to end, not to drain.
+			drainDelay := cfg.DrainDelay
+			if err != nil {
+				drainDelay = 0
+			}
+			shutdownErr, exitErrs := cfg.shutdown(ctx, group, active, exits, running, drainDelay)
+			running = 0
+			hookErr = errors.Join(hookErr, cfg.runHook(ctx, "OnDrained", cfg.OnDrained))
+			if err == nil {
+				if shutdownErr != nil {
+					err = joinErrors(append(early, shutdownErr)...)
+				} else {
+					err = joinErrors(append(early, exitErrs...)...)
+				}
+			} else {
+				err = joinErrors(append(append([]error{err}, early...), exitErrs...)...)
+			}
+		}
+	}
+	if hookErr = errors.Join(hookErr, cfg.runHook(ctx, "OnStopped", cfg.OnStopped)); hookErr != nil {
+		err = errors.Join(err, hookErr)
+	}
+	return
+}
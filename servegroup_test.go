@@ -0,0 +1,127 @@
+package webserv_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/linkdata/webserv"
+)
+
+func newGroupMember(t *testing.T, body string) webserv.ServerListener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return webserv.ServerListener{
+		Server: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, body)
+		})},
+		Listener: l,
+	}
+}
+
+func getBody(addr net.Addr) (body string, err error) {
+	var resp *http.Response
+	if resp, err = http.Get("http://" + addr.String()); err == nil {
+		var b []byte
+		b, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		body = string(b)
+	}
+	return
+}
+
+func TestConfigServeGroup_ServesAllAndStopsTogether(t *testing.T) {
+	public := newGroupMember(t, "public")
+	admin := newGroupMember(t, "admin")
+	listening := make(chan struct{})
+	cfg := &webserv.Config{
+		OnListening: func(context.Context) error {
+			close(listening)
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeGroup(ctx, public, admin) }()
+	<-listening
+
+	for want, member := range map[string]webserv.ServerListener{"public": public, "admin": admin} {
+		body, err := getBody(member.Listener.Addr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != want {
+			t.Fatalf("body = %q, want %q", body, want)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("ServeGroup() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("ServeGroup() did not return after context cancellation")
+	}
+	for _, member := range []webserv.ServerListener{public, admin} {
+		if _, err := getBody(member.Listener.Addr()); err == nil {
+			t.Fatalf("server on %v still serving after ServeGroup returned", member.Listener.Addr())
+		}
+	}
+}
+
+func TestConfigServeGroup_FailingServerStopsTheRest(t *testing.T) {
+	public := newGroupMember(t, "public")
+	failing := webserv.ServerListener{Server: &http.Server{}, Listener: panicListener{}}
+	var shutdownStarted bool
+	cfg := &webserv.Config{
+		OnShutdownStart: func(context.Context) error {
+			shutdownStarted = true
+			return nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeGroup(t.Context(), public, failing) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("ServeGroup() did not return after a server failed")
+	}
+	if !errors.Is(err, webserv.ErrServePanic) {
+		t.Fatalf("ServeGroup() error = %v, want match %v", err, webserv.ErrServePanic)
+	}
+	if !shutdownStarted {
+		t.Fatal("remaining server was not shut down")
+	}
+	if _, err = getBody(public.Listener.Addr()); err == nil {
+		t.Fatal("remaining server still serving after ServeGroup returned")
+	}
+}
+
+func TestConfigServeGroup_InvalidGroupPanics(t *testing.T) {
+	cfg := &webserv.Config{}
+	assertPanics(t, "webserv: empty server group", func() {
+		_ = cfg.ServeGroup(t.Context())
+	})
+	member := newGroupMember(t, "")
+	assertPanics(t, "webserv: nil http.Server", func() {
+		_ = cfg.ServeGroup(t.Context(), member, webserv.ServerListener{Listener: member.Listener})
+	})
+	assertPanics(t, "webserv: nil net.Listener", func() {
+		_ = cfg.ServeGroup(t.Context(), webserv.ServerListener{Server: member.Server})
+	})
+}
@@ -0,0 +1,94 @@
+package webserv
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// trackConns wraps srv.ConnState to count the connections srv manages,
+// chaining to the previous hook. Like [installTLSErrorLogFilter] it must be
+// called before [net/http.Server.Serve] starts, and the wrapper stays
+// installed afterwards.
+func trackConns(srv *http.Server) (active *atomic.Int64) {
+	active = new(atomic.Int64)
+	previous := srv.ConnState
+	srv.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			active.Add(1)
+		case http.StateHijacked, http.StateClosed:
+			active.Add(-1)
+		}
+		if previous != nil {
+			previous(conn, state)
+		}
+	}
+	return
+}
+
+// shutdown stops the servers in group after a shutdown was requested, while
+// running of them are still serving. It keeps serving for drainDelay, or
+// until ctx is canceled, then shuts all the servers down concurrently and
+// waits for the connections registered with [Config.TrackHijacked], all
+// bounded by a single cfg.ShutdownTimeLimit. If that times out it closes the servers and the
+// hijacked connections, so that they cannot keep the process alive, and
+// reports how many were still open. active holds the connection counts
+// maintained by [trackConns].
+//
+// It returns the error from the shutdown and the non-clean errors the
+// remaining Serve calls returned, as received from exits.
+func (cfg *Config) shutdown(ctx context.Context, group []ServerListener, active []*atomic.Int64, exits <-chan serveExit, running int, drainDelay time.Duration) (shutdownErr error, exitErrs []error) {
+	collect := func(exit serveExit) {
+		running--
+		if !isCleanServerClosed(exit.err) {
+			exitErrs = append(exitErrs, exit.err)
+		}
+	}
+	if drainDelay > 0 && running > 0 {
+		cfg.logInfo("draining", "delay", drainDelay)
+		timer := time.NewTimer(drainDelay)
+		for draining := true; draining && running > 0; {
+			select {
+			case <-timer.C:
+				draining = false
+			case <-ctx.Done():
+				draining = false
+			case exit := <-exits:
+				collect(exit)
+			}
+		}
+		timer.Stop()
+	}
+	inst := cfg.instance()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.shutdownTimeLimit())
+	inst.hijacked.stop()
+	shutdownErrs := make([]error, len(group))
+	var wg sync.WaitGroup
+	for i, member := range group {
+		wg.Go(func() { shutdownErrs[i] = member.Server.Shutdown(shutdownCtx) })
+	}
+	wg.Wait()
+	if shutdownErr = joinErrors(shutdownErrs...); shutdownErr == nil {
+		shutdownErr = inst.hijacked.wait(shutdownCtx)
+	}
+	shutdownCancel()
+	if shutdownErr != nil {
+		var closed int
+		for i, member := range group {
+			closed += max(int(active[i].Load()), 0)
+			_ = member.Server.Close()
+		}
+		hijacked := inst.hijacked.closeAll()
+		closed += hijacked
+		cfg.logWarn("shutdown timed out, connections closed", "count", closed, "hijacked", hijacked, "err", shutdownErr)
+		shutdownErr = newErrShutdown(closed, hijacked, shutdownErr)
+	}
+	for running > 0 {
+		collect(<-exits)
+	}
+	return
+}
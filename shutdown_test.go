@@ -0,0 +1,271 @@
+package webserv_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/linkdata/webserv"
+)
+
+func TestConfigServeGroup_DrainDelayKeepsServingWhileNotReady(t *testing.T) {
+	public := newGroupMember(t, "ok")
+	admin := newGroupMember(t, "admin")
+	draining := newNotifyingLogger(io.Discard, "webserv: draining")
+	const drainDelay = 300 * time.Millisecond
+	cfg := &webserv.Config{
+		DrainDelay: drainDelay,
+		Logger:     draining,
+	}
+
+	if cfg.Ready() {
+		t.Fatal("Ready() = true before ServeGroup")
+	}
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeGroup(t.Context(), public, admin) }()
+	for deadline := time.Now().Add(time.Second); !cfg.Ready(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Ready() = false while serving")
+		}
+	}
+
+	// A server stopping on its own shuts the others down after the delay.
+	start := time.Now()
+	_ = admin.Server.Close()
+	<-draining.ready
+	if cfg.Ready() {
+		t.Fatal("Ready() = true while draining")
+	}
+	if body, err := getBody(public.Listener.Addr()); err != nil || body != "ok" {
+		t.Fatalf("request during drain delay = %q, %v", body, err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("ServeGroup() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < drainDelay {
+		t.Fatalf("ServeGroup() returned after %v, before the %v drain delay", elapsed, drainDelay)
+	}
+	if cfg.Ready() {
+		t.Fatal("Ready() = true after ServeGroup returned")
+	}
+}
+
+func TestConfigServeWith_CanceledContextSkipsDrainDelay(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listening := make(chan struct{})
+	cfg := &webserv.Config{
+		DrainDelay: time.Minute,
+		OnListening: func(context.Context) error {
+			close(listening)
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, &http.Server{}, l) }()
+	<-listening
+	cancel()
+
+	select {
+	case err = <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("ServeWith() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("ServeWith() waited out the drain delay after ctx was canceled")
+	}
+}
+
+func TestConfigServeGroup_CancelDuringDrainDelayEndsIt(t *testing.T) {
+	public := newGroupMember(t, "ok")
+	admin := newGroupMember(t, "admin")
+	draining := newNotifyingLogger(io.Discard, "webserv: draining")
+	cfg := &webserv.Config{
+		DrainDelay: time.Minute,
+		Logger:     draining,
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeGroup(ctx, public, admin) }()
+	for deadline := time.Now().Add(time.Second); !cfg.Ready(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Ready() = false while serving")
+		}
+	}
+	_ = admin.Server.Close()
+	<-draining.ready
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("ServeGroup() waited out the drain delay after ctx was canceled")
+	}
+}
+
+func TestConfigServeWith_ShutdownTimeoutClosesConnections(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	closed := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(closed)
+	})}
+	cfg := &webserv.Config{ShutdownTimeLimit: 20 * time.Millisecond}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, srv, l) }()
+	go func() {
+		if resp, err := http.Get("http://" + l.Addr().String()); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("ServeWith() error = %v, want %v", err, context.Canceled)
+	}
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("active connection survived the shutdown time limit")
+	}
+}
+
+func TestConfigServeWith_ShutdownTimeoutReportsClosedConnections(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process signalling from tests is not reliable on windows")
+	}
+	signalTestMu.Lock()
+	defer signalTestMu.Unlock()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})}
+	cfg := &webserv.Config{ShutdownTimeLimit: 20 * time.Millisecond}
+
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(t.Context(), srv, l) }()
+	go func() {
+		if resp, err := http.Get("http://" + l.Addr().String()); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-started
+	if err = signalSelf(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	err = <-done
+	var se webserv.ShutdownError
+	if !errors.As(err, &se) || !errors.Is(err, webserv.ErrShutdown) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ServeWith() error = %v, want ShutdownError matching %v", err, context.DeadlineExceeded)
+	}
+	if se.Closed != 1 || se.Hijacked != 0 {
+		t.Fatalf("ShutdownError = %+v, want one closed connection, none hijacked", se)
+	}
+}
+
+// serveHijacking serves a handler that hijacks the connection, registers it
+// with cfg.TrackHijacked and passes it to handle, and returns the client side
+// of such a connection.
+func serveHijacking(t *testing.T, ctx context.Context, cfg *webserv.Config, handle func(conn net.Conn, notified <-chan struct{}, untrack func())) (client net.Conn, done <-chan error) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracked := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		notified := make(chan struct{})
+		untrack := cfg.TrackHijacked(conn, func() { close(notified) })
+		close(tracked)
+		handle(conn, notified, untrack)
+	})}
+	errc := make(chan error, 1)
+	go func() { errc <- cfg.ServeWith(ctx, srv, l) }()
+	if client, err = net.Dial("tcp", l.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	if _, err = client.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	<-tracked
+	return client, errc
+}
+
+func TestConfigServeWith_NotifiesAndWaitsForHijackedConnections(t *testing.T) {
+	cfg := &webserv.Config{ShutdownTimeLimit: time.Second}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	released := make(chan struct{})
+	_, done := serveHijacking(t, ctx, cfg, func(conn net.Conn, notified <-chan struct{}, untrack func()) {
+		<-notified
+		time.Sleep(50 * time.Millisecond)
+		_ = conn.Close()
+		close(released)
+		untrack()
+		untrack()
+	})
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("ServeWith() error = %v, want %v", err, context.Canceled)
+	}
+	select {
+	case <-released:
+	default:
+		t.Fatal("ServeWith() returned before the hijacked connection was released")
+	}
+}
+
+func TestConfigServeWith_ClosesHijackedConnectionsAfterTimeLimit(t *testing.T) {
+	cfg := &webserv.Config{ShutdownTimeLimit: 20 * time.Millisecond}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	client, done := serveHijacking(t, ctx, cfg, func(net.Conn, <-chan struct{}, func()) {})
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("ServeWith() error = %v, want %v", err, context.Canceled)
+	}
+	if err := client.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Fatalf("Read() on hijacked connection = %v, want %v", err, io.EOF)
+	}
+}
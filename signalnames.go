@@ -0,0 +1,40 @@
+package webserv
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// signalName returns the name of sig as accepted by parseSignal, such as
+// "SIGTERM", or sig.String() if it has none.
+func signalName(sig os.Signal) string {
+	for _, names := range []map[string]os.Signal{commonSignals, extraSignals} {
+		for name, s := range names {
+			if s == sig {
+				return "SIG" + name
+			}
+		}
+	}
+	return sig.String()
+}
+
+// commonSignals are the signals by name, without the "SIG" prefix, that every
+// platform supports.
+var commonSignals = map[string]os.Signal{
+	"INT":  os.Interrupt,
+	"TERM": syscall.SIGTERM,
+}
+
+// parseSignal parses a signal name such as "SIGUSR1" or "usr1".
+func parseSignal(s string) (sig os.Signal, err error) {
+	name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "SIG")
+	var ok bool
+	if sig, ok = commonSignals[name]; !ok {
+		if sig, ok = extraSignals[name]; !ok {
+			err = fmt.Errorf("unknown signal %q", s)
+		}
+	}
+	return
+}
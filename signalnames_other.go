@@ -0,0 +1,9 @@
+//go:build !(unix || linux)
+
+package webserv
+
+import "os"
+
+// extraSignals are the signals by name, without the "SIG" prefix, supported
+// on this platform in addition to commonSignals.
+var extraSignals = map[string]os.Signal{}
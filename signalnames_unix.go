@@ -0,0 +1,17 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"os"
+	"syscall"
+)
+
+// extraSignals are the signals by name, without the "SIG" prefix, supported
+// on this platform in addition to commonSignals.
+var extraSignals = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"QUIT": syscall.SIGQUIT,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
@@ -0,0 +1,113 @@
+package webserv
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// SignalAction is what [Config.ServeWith] does when it receives a signal
+// listed in [Config.Signals]. The zero value is not a valid action; signals
+// mapped to it are handled, but ignored with a log message.
+type SignalAction int
+
+const (
+	// SignalShutdown starts a controlled shutdown. Once it has started, the
+	// signals mapped to SignalShutdown get their default behavior back, so a
+	// second one usually terminates the process.
+	SignalShutdown SignalAction = iota + 1
+	// SignalReload calls [Config.Reload].
+	SignalReload
+	// SignalDump calls [Config.Dump].
+	SignalDump
+	// SignalIgnore discards the signal while serving.
+	SignalIgnore
+)
+
+func (a SignalAction) String() string {
+	switch a {
+	case SignalShutdown:
+		return "shutdown"
+	case SignalReload:
+		return "reload"
+	case SignalDump:
+		return "dump"
+	case SignalIgnore:
+		return "ignore"
+	}
+	return "SignalAction(" + strconv.Itoa(int(a)) + ")"
+}
+
+// parseSignalAction parses the name of a SignalAction as returned by its
+// String method.
+func parseSignalAction(s string) (action SignalAction, err error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "shutdown":
+		action = SignalShutdown
+	case "reload":
+		action = SignalReload
+	case "dump":
+		action = SignalDump
+	case "ignore":
+		action = SignalIgnore
+	default:
+		err = fmt.Errorf("unknown signal action %q", s)
+	}
+	return
+}
+
+// DefaultSignals returns the signal handling [Config.ServeWith] uses when
+// [Config.Signals] is nil: SIGINT and SIGTERM shut down, and on Unix SIGHUP
+// reloads. The returned map is new, so it may be modified and assigned to
+// [Config.Signals].
+func DefaultSignals() (actions map[os.Signal]SignalAction) {
+	actions = map[os.Signal]SignalAction{
+		os.Interrupt:    SignalShutdown,
+		syscall.SIGTERM: SignalShutdown,
+	}
+	maps.Copy(actions, extraDefaultSignals)
+	return
+}
+
+// signalActions returns the signals ServeWith handles and what to do on
+// each, combining cfg.Signals (or the defaults) with cfg.DumpSignal.
+func (cfg *Config) signalActions() (actions map[os.Signal]SignalAction) {
+	if !cfg.DisableSignals {
+		if cfg.Signals == nil {
+			actions = DefaultSignals()
+		} else {
+			actions = make(map[os.Signal]SignalAction, len(cfg.Signals)+1)
+			for sig, action := range cfg.Signals {
+				actions[sig] = action
+			}
+		}
+		if cfg.DumpSignal != nil {
+			if _, ok := actions[cfg.DumpSignal]; !ok {
+				actions[cfg.DumpSignal] = SignalDump
+			}
+		}
+	}
+	return
+}
+
+// notifySignals relays the signals in actions mapped to SignalShutdown to
+// shutdownSig and the others to otherSig.
+func notifySignals(actions map[os.Signal]SignalAction, shutdownSig, otherSig chan<- os.Signal) {
+	for sig, action := range actions {
+		if action == SignalShutdown {
+			signal.Notify(shutdownSig, sig)
+		} else {
+			signal.Notify(otherSig, sig)
+		}
+	}
+}
+
+// errSignal is the cause of a shutdown started by a signal.
+func errSignal(sig os.Signal) error {
+	return errors.New(sig.String() + " signal received")
+}
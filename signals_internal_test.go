@@ -0,0 +1,59 @@
+package webserv
+
+import (
+	"maps"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestSignalActions_DefaultsAndDumpSignal(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.signalActions(); !maps.Equal(got, DefaultSignals()) {
+		t.Fatalf("signalActions() = %v, want defaults %v", got, DefaultSignals())
+	}
+
+	cfg.DumpSignal = syscall.SIGTERM
+	if got := cfg.signalActions()[syscall.SIGTERM]; got != SignalShutdown {
+		t.Fatalf("DumpSignal overrode default mapping: SIGTERM = %v", got)
+	}
+
+	cfg.Signals = map[os.Signal]SignalAction{os.Interrupt: SignalIgnore}
+	want := map[os.Signal]SignalAction{os.Interrupt: SignalIgnore, syscall.SIGTERM: SignalDump}
+	if got := cfg.signalActions(); !maps.Equal(got, want) {
+		t.Fatalf("signalActions() = %v, want %v", got, want)
+	}
+	if len(cfg.Signals) != 1 {
+		t.Fatalf("signalActions() modified cfg.Signals: %v", cfg.Signals)
+	}
+
+	cfg.DisableSignals = true
+	if got := cfg.signalActions(); len(got) != 0 {
+		t.Fatalf("signalActions() = %v with DisableSignals, want none", got)
+	}
+}
+
+func TestSignalAction_ZeroValueIsInvalid(t *testing.T) {
+	var unset SignalAction
+	if unset == SignalShutdown {
+		t.Fatal("the zero SignalAction is SignalShutdown")
+	}
+	if _, err := parseSignalAction(unset.String()); err == nil {
+		t.Fatalf("parseSignalAction(%q) succeeded", unset.String())
+	}
+}
+
+func TestSignalAction_String(t *testing.T) {
+	for action, want := range map[SignalAction]string{
+		SignalShutdown:  "shutdown",
+		SignalReload:    "reload",
+		SignalDump:      "dump",
+		SignalIgnore:    "ignore",
+		SignalAction(0): "SignalAction(0)",
+		SignalAction(9): "SignalAction(9)",
+	} {
+		if got := action.String(); got != want {
+			t.Errorf("SignalAction(%d).String() = %q, want %q", int(action), got, want)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !(unix || linux)
+
+package webserv
+
+import "os"
+
+// extraDefaultSignals are the entries of DefaultSignals for signals that only
+// exist on this platform.
+var extraDefaultSignals = map[os.Signal]SignalAction{}
@@ -0,0 +1,14 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"os"
+	"syscall"
+)
+
+// extraDefaultSignals are the entries of DefaultSignals for signals that only
+// exist on this platform.
+var extraDefaultSignals = map[os.Signal]SignalAction{
+	syscall.SIGHUP: SignalReload,
+}
@@ -0,0 +1,145 @@
+package webserv
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// State is the lifecycle state of a [Config], driven by [Config.Listen] and
+// [Config.ServeWith].
+type State int
+
+const (
+	// StateStarting is the state before and during [Config.Listen].
+	StateStarting State = iota
+	// StateListening is the state after [Config.Listen] succeeded and before
+	// serving starts.
+	StateListening
+	// StateServing is the state while [Config.ServeWith] serves requests
+	// and no shutdown has been requested.
+	StateServing
+	// StateDraining is the state once a shutdown has been requested, while
+	// [Config.DrainDelay] elapses and connections are shut down.
+	StateDraining
+	// StateStopped is the state after [Config.ServeWith] returned without
+	// error, or with just the error of its canceled context.
+	StateStopped
+	// StateFailed is the state after [Config.Listen] or [Config.ServeWith]
+	// returned any other error.
+	StateFailed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateStarting:
+		return "starting"
+	case StateListening:
+		return "listening"
+	case StateServing:
+		return "serving"
+	case StateDraining:
+		return "draining"
+	case StateStopped:
+		return "stopped"
+	case StateFailed:
+		return "failed"
+	}
+	return "State(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Transition records a change of [State].
+type Transition struct {
+	From State     // state left
+	To   State     // state entered
+	Time time.Time // when the change happened
+	Err  error     // for StateFailed, the error returned
+}
+
+// Status is a snapshot of the lifecycle of a [Config], for health endpoints
+// and admin pages.
+type Status struct {
+	State     State     // current state
+	Since     time.Time // when State was entered; zero before the first transition
+	StartTime time.Time // when Listen was last called, or ServeWith if it was called without Listen
+	ListenURL string    // cfg.ListenURL as of the last successful Listen or start of serving
+	LastErr   error     // the error of the last transition to StateFailed, if any
+}
+
+// maxTransitions is the number of transitions kept for Transitions.
+const maxTransitions = 64
+
+// lifecycle holds the state of a Config.
+type lifecycle struct {
+	notifyMu    sync.Mutex // held by setState, so OnStateChange sees transitions in order
+	mu          sync.Mutex // guards status and transitions
+	status      Status
+	transitions []Transition
+}
+
+// setState records a transition of cfg to state and calls
+// cfg.OnStateChange. err is kept as the last error if state is StateFailed.
+func (cfg *Config) setState(state State, err error) {
+	lc := &cfg.instance().lifecycle
+	lc.notifyMu.Lock()
+	defer lc.notifyMu.Unlock()
+	lc.mu.Lock()
+	t := Transition{From: lc.status.State, To: state, Time: time.Now(), Err: err}
+	switch state {
+	case StateStarting:
+		lc.status.StartTime = t.Time
+	case StateListening:
+		lc.status.ListenURL = cfg.ListenURL
+	case StateServing:
+		if t.From != StateListening {
+			lc.status.StartTime = t.Time
+			lc.status.ListenURL = cfg.ListenURL
+		}
+	case StateFailed:
+		lc.status.LastErr = err
+	}
+	lc.status.State = state
+	lc.status.Since = t.Time
+	if len(lc.transitions) >= maxTransitions {
+		lc.transitions = lc.transitions[len(lc.transitions)-maxTransitions+1:]
+	}
+	lc.transitions = append(lc.transitions, t)
+	lc.mu.Unlock()
+	if cfg.OnStateChange != nil {
+		cfg.OnStateChange(t)
+	}
+}
+
+// State returns the current lifecycle state of cfg.
+func (cfg *Config) State() State {
+	lc := &cfg.instance().lifecycle
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.status.State
+}
+
+// Status returns a snapshot of the lifecycle of cfg.
+func (cfg *Config) Status() Status {
+	lc := &cfg.instance().lifecycle
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.status
+}
+
+// Transitions returns the most recent state transitions of cfg, at most 64,
+// oldest first.
+func (cfg *Config) Transitions() []Transition {
+	lc := &cfg.instance().lifecycle
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return append([]Transition(nil), lc.transitions...)
+}
+
+// Ready reports whether [Config.ServeWith] is serving and has not yet begun
+// shutting down, that is, whether cfg is in [StateServing]. It turns false as
+// soon as a shutdown is requested, before [Config.DrainDelay] elapses, so
+// readiness probes can take the instance out of load balancer rotation while
+// requests are still being served.
+func (cfg *Config) Ready() bool {
+	return cfg.State() == StateServing
+}
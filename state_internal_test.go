@@ -0,0 +1,34 @@
+package webserv
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestConfigSetState_CapsTransitions(t *testing.T) {
+	cfg := &Config{}
+	for i := range 3 * maxTransitions {
+		cfg.setState(State(i%int(StateFailed+1)), nil)
+	}
+	transitions := cfg.Transitions()
+	if len(transitions) != maxTransitions {
+		t.Fatalf("kept %d transitions, want %d", len(transitions), maxTransitions)
+	}
+	if last := transitions[len(transitions)-1].To; last != State((3*maxTransitions-1)%int(StateFailed+1)) {
+		t.Fatalf("last transition to %v, want the most recent one", last)
+	}
+}
+
+func TestConfigSetState_CallbacksInOrder(t *testing.T) {
+	var observed []Transition
+	cfg := &Config{OnStateChange: func(t Transition) { observed = append(observed, t) }}
+	var wg sync.WaitGroup
+	for i := range 32 {
+		wg.Go(func() { cfg.setState(State(i%int(StateFailed+1)), nil) })
+	}
+	wg.Wait()
+	if !slices.Equal(observed, cfg.Transitions()) {
+		t.Fatalf("OnStateChange saw transitions in a different order than Transitions()")
+	}
+}
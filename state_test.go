@@ -0,0 +1,153 @@
+package webserv_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linkdata/webserv"
+)
+
+func transitionStates(transitions []webserv.Transition) (states []webserv.State) {
+	for _, t := range transitions {
+		states = append(states, t.To)
+	}
+	return
+}
+
+func TestConfigState_ListenAndServeTransitions(t *testing.T) {
+	var mu sync.Mutex
+	var observed []webserv.Transition
+	listening := make(chan struct{})
+	cfg := &webserv.Config{
+		Address: "127.0.0.1:0",
+		OnStateChange: func(t webserv.Transition) {
+			mu.Lock()
+			observed = append(observed, t)
+			mu.Unlock()
+		},
+		OnListening: func(context.Context) error {
+			close(listening)
+			return nil
+		},
+	}
+	if got := cfg.State(); got != webserv.StateStarting {
+		t.Fatalf("State() = %v before Listen, want %v", got, webserv.StateStarting)
+	}
+	before := time.Now()
+	l, err := cfg.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.State(); got != webserv.StateListening {
+		t.Fatalf("State() = %v after Listen, want %v", got, webserv.StateListening)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- cfg.ServeWith(ctx, &http.Server{}, l) }()
+	<-listening
+	status := cfg.Status()
+	if status.State != webserv.StateServing || !cfg.Ready() {
+		t.Fatalf("Status().State = %v, Ready() = %v while serving", status.State, cfg.Ready())
+	}
+	if status.StartTime.Before(before) || status.Since.Before(status.StartTime) {
+		t.Fatalf("Status() times = start %v, since %v; want start after %v", status.StartTime, status.Since, before)
+	}
+	if status.ListenURL == "" || status.ListenURL != cfg.ListenURL {
+		t.Fatalf("Status().ListenURL = %q, want %q", status.ListenURL, cfg.ListenURL)
+	}
+	cancel()
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("ServeWith() = %v, want %v", err, context.Canceled)
+	}
+
+	want := []webserv.State{webserv.StateStarting, webserv.StateListening, webserv.StateServing, webserv.StateDraining, webserv.StateStopped}
+	transitions := cfg.Transitions()
+	if got := transitionStates(transitions); !slices.Equal(got, want) {
+		t.Fatalf("Transitions() = %v, want %v", got, want)
+	}
+	for i := 1; i < len(transitions); i++ {
+		if transitions[i].From != transitions[i-1].To || transitions[i].Time.Before(transitions[i-1].Time) {
+			t.Fatalf("transition %d = %+v does not follow %+v", i, transitions[i], transitions[i-1])
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(observed, transitions) {
+		t.Fatalf("OnStateChange saw %v, want %v", observed, transitions)
+	}
+	if status = cfg.Status(); status.State != webserv.StateStopped || status.LastErr != nil {
+		t.Fatalf("Status() = %+v after ServeWith, want stopped without error", status)
+	}
+}
+
+func TestConfigState_ListenFailure(t *testing.T) {
+	cfg := &webserv.Config{Address: "127.0.0.1:99999"}
+	if _, err := cfg.Listen(); err == nil {
+		t.Fatal("Listen() succeeded")
+	}
+	status := cfg.Status()
+	if status.State != webserv.StateFailed || !errors.Is(status.LastErr, webserv.ErrListen) {
+		t.Fatalf("Status() = %+v, want failed with %v", status, webserv.ErrListen)
+	}
+	if status.ListenURL != "" {
+		t.Fatalf("Status().ListenURL = %q after failed Listen, want empty", status.ListenURL)
+	}
+}
+
+func TestConfigState_ServeFailureWithoutListen(t *testing.T) {
+	cfg := &webserv.Config{}
+	err := cfg.ServeWith(t.Context(), &http.Server{}, panicListener{})
+	status := cfg.Status()
+	if status.State != webserv.StateFailed || status.LastErr != err || !errors.Is(err, webserv.ErrServePanic) {
+		t.Fatalf("Status() = %+v for ServeWith() = %v, want failed with that error", status, err)
+	}
+	if status.StartTime.IsZero() {
+		t.Fatal("Status().StartTime not set by ServeWith without Listen")
+	}
+	want := []webserv.State{webserv.StateServing, webserv.StateFailed}
+	if got := transitionStates(cfg.Transitions()); !slices.Equal(got, want) {
+		t.Fatalf("Transitions() = %v, want %v", got, want)
+	}
+}
+
+func TestState_String(t *testing.T) {
+	for state, want := range map[webserv.State]string{
+		webserv.StateStarting:  "starting",
+		webserv.StateListening: "listening",
+		webserv.StateServing:   "serving",
+		webserv.StateDraining:  "draining",
+		webserv.StateStopped:   "stopped",
+		webserv.StateFailed:    "failed",
+		webserv.State(42):      "State(42)",
+	} {
+		if got := state.String(); got != want {
+			t.Errorf("State(%d).String() = %q, want %q", int(state), got, want)
+		}
+	}
+}
+
+func TestConfigState_CopiesBeforeListenAreIndependent(t *testing.T) {
+	orig := webserv.Config{Address: "127.0.0.1:0"}
+	cp := orig
+	l, err := orig.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if got := orig.State(); got != webserv.StateListening {
+		t.Fatalf("State() = %v after Listen, want %v", got, webserv.StateListening)
+	}
+	if got := cp.State(); got != webserv.StateStarting || len(cp.Transitions()) != 0 {
+		t.Fatalf("copy State() = %v with %d transitions, want untouched", got, len(cp.Transitions()))
+	}
+	shared := orig
+	if got := shared.State(); got != webserv.StateListening {
+		t.Fatalf("copy after Listen State() = %v, want %v", got, webserv.StateListening)
+	}
+}
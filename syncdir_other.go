@@ -0,0 +1,9 @@
+//go:build !(unix || linux)
+
+package webserv
+
+import "errors"
+
+func isSyncDirUnsupported(err error) bool {
+	return errors.Is(err, errors.ErrUnsupported)
+}
@@ -0,0 +1,14 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isSyncDirUnsupported reports whether err is how fsync says the file system
+// cannot sync a directory: EINVAL on Linux, ENOTSUP on others.
+func isSyncDirUnsupported(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP) || errors.Is(err, errors.ErrUnsupported)
+}
@@ -0,0 +1,31 @@
+//go:build unix || linux
+
+package webserv
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestDataDir_WriteFileToleratesUnsyncableDir(t *testing.T) {
+	d, err := OpenDataDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	defer func(fn func(*os.File) error) { syncDirFn = fn }(syncDirFn)
+
+	for _, errno := range []syscall.Errno{syscall.EINVAL, syscall.ENOTSUP} {
+		syncDirFn = func(f *os.File) error { return &fs.PathError{Op: "sync", Path: f.Name(), Err: errno} }
+		if err = d.WriteFile("state", []byte("x"), 0o600); err != nil {
+			t.Errorf("WriteFile() with fsync failing with %v = %v, want nil", errno, err)
+		}
+	}
+	syncDirFn = func(f *os.File) error { return &fs.PathError{Op: "sync", Path: f.Name(), Err: syscall.EIO} }
+	if err = d.WriteFile("state", []byte("x"), 0o600); !errors.Is(err, syscall.EIO) {
+		t.Errorf("WriteFile() with fsync failing with EIO = %v, want %v", err, syscall.EIO)
+	}
+}
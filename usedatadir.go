@@ -1,15 +1,24 @@
 package webserv
 
 import (
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 )
 
+var errNotDirectory = errors.New("not a directory")
+
 // DefaultDataDir returns the absolute path to dataDir if not empty, otherwise if
 // defaultSuffix is not empty it returns the absolute joined path
 // of [os.UserConfigDir] and defaultSuffix.
 //
+// When dataDir is empty and defaultSuffix is not, the directory systemd passes
+// in the STATE_DIRECTORY environment variable (for units using
+// StateDirectory=) is preferred and used as is, without the suffix. If it
+// lists several directories, the first is used.
+//
 // It expands environment variables in the caller-supplied dataDir and
 // defaultSuffix before evaluating the absolute path. The [os.UserConfigDir]
 // base is system-provided and is not expanded, so a literal "$" in it survives.
@@ -20,25 +29,62 @@ import (
 // dataDir and defaultSuffix may contain paths, ".." segments and symlinks.
 // They are not confined to the user config directory, so they may resolve
 // outside of it. Caller is responsible for validating or sandboxing untrusted
-// path input.
+// path input, or can use [DefaultDataDirStrict].
+//
+// Returns an error matching [ErrDataDir] on failure.
 func DefaultDataDir(dataDir, defaultSuffix string) (result string, err error) {
+	return defaultDir(dataDir, systemdDataDir(dataDir, defaultSuffix), defaultSuffix, os.UserConfigDir, false)
+}
+
+// DefaultDataDirStrict is like [DefaultDataDir], except that defaultSuffix
+// must resolve inside [os.UserConfigDir]. A suffix that is not local or leads
+// outside through a symlink fails with an error matching both [ErrDataDir] and
+// [ErrPathEscape]. dataDir and STATE_DIRECTORY are trusted and not confined.
+func DefaultDataDirStrict(dataDir, defaultSuffix string) (result string, err error) {
+	return defaultDir(dataDir, systemdDataDir(dataDir, defaultSuffix), defaultSuffix, os.UserConfigDir, true)
+}
+
+// systemdDataDir returns the systemd STATE_DIRECTORY if dataDir is empty and a
+// default data directory was asked for with defaultSuffix.
+func systemdDataDir(dataDir, defaultSuffix string) (dir string) {
+	if dataDir == "" && defaultSuffix != "" {
+		dir = systemdDirectory("STATE_DIRECTORY")
+	}
+	return
+}
+
+// defaultDir implements [DefaultDataDir] with base providing the directory
+// that defaultSuffix is appended to, confining it there if strict is set.
+// systemdDir, if not empty, is used verbatim when dataDir is empty.
+func defaultDir(dataDir, systemdDir, defaultSuffix string, base func() (string, error), strict bool) (result string, err error) {
 	// A non-empty dataDir suppresses the defaultSuffix fallback even if it later
 	// expands to empty, so the emptiness test uses the raw (unexpanded) value.
 	if dataDir != "" {
 		result = os.ExpandEnv(dataDir)
+	} else if systemdDir != "" {
+		result = systemdDir
 	} else if defaultSuffix != "" {
 		if defaultSuffix = os.ExpandEnv(defaultSuffix); defaultSuffix != "" {
-			if result, err = os.UserConfigDir(); err == nil {
-				result = filepath.Join(result, defaultSuffix)
+			if result, err = base(); err == nil {
+				if strict {
+					result, err = ConfinedPath(result, defaultSuffix)
+				} else {
+					result = filepath.Join(result, defaultSuffix)
+				}
 			}
 		}
 	}
+	path := result
 	if err == nil && result != "" {
 		// A non-empty input may expand to empty (e.g. "$HOME" with HOME unset);
 		// filepath.Abs("") would resolve to the current working directory rather
 		// than leaving result empty, so guard against it.
 		result, err = filepath.Abs(result)
 	}
+	if err != nil && path == "" {
+		path = defaultSuffix
+	}
+	err = newErrDataDir(path, err)
 	return
 }
 
@@ -48,15 +94,45 @@ func DefaultDataDir(dataDir, defaultSuffix string) (result string, err error) {
 // directory's permissions may be more restrictive than mode. Does nothing if
 // dataDir is empty. Does not expand environment variables in the path.
 //
-// Returns the final path or an empty string if dataDir was empty.
+// Returns the final path or an empty string if dataDir was empty, and an error
+// matching [ErrDataDir] on failure.
 func UseDataDir(dataDir string, mode fs.FileMode) (string, error) {
 	var err error
 	if dataDir != "" {
+		path := dataDir
 		if dataDir, err = filepath.Abs(dataDir); err == nil {
+			path = dataDir
 			if mode != 0 {
 				err = os.MkdirAll(dataDir, mode)
 			}
 		}
+		err = newErrDataDir(path, err)
 	}
 	return dataDir, err
 }
+
+// CheckDataDir verifies that dataDir is a directory owned by the effective
+// user of the process and that none of the permission bits in forbiddenPerm
+// are set on it. It follows symlinks. Does nothing if dataDir is empty.
+//
+// Ownership and permission failures wrap [fs.ErrPermission] and name the
+// offending owner or mode. Ownership cannot be checked on Windows, where it
+// returns an error matching [errors.ErrUnsupported].
+//
+// Returns an error matching [ErrDataDir] on failure.
+func CheckDataDir(dataDir string, forbiddenPerm fs.FileMode) (err error) {
+	if dataDir != "" {
+		var fi fs.FileInfo
+		if fi, err = os.Stat(dataDir); err == nil {
+			if !fi.IsDir() {
+				err = errNotDirectory
+			} else if err = checkOwner(fi); err == nil {
+				if bad := fi.Mode().Perm() & forbiddenPerm; bad != 0 {
+					err = fmt.Errorf("mode %v grants forbidden permissions %v: %w", fi.Mode().Perm(), bad, fs.ErrPermission)
+				}
+			}
+		}
+		err = newErrDataDir(dataDir, err)
+	}
+	return
+}
@@ -1,6 +1,7 @@
 package webserv_test
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -155,3 +156,69 @@ func TestDefaultDataDir_AbsoluteSuffixCannotEscapeUserConfigDir(t *testing.T) {
 		t.Fatalf("DefaultDataDir escaped UserConfigDir: base=%q got=%q", base, got)
 	}
 }
+
+func TestUseDataDir_ErrorMatchesErrDataDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dataDir := filepath.Join(file, "sub")
+	got, err := webserv.UseDataDir(dataDir, 0o750)
+	if !errors.Is(err, webserv.ErrDataDir) {
+		t.Fatalf("UseDataDir(%q) error = %v, want match %v", dataDir, err, webserv.ErrDataDir)
+	}
+	var dde webserv.DataDirError
+	if !errors.As(err, &dde) {
+		t.Fatalf("UseDataDir(%q) error = %T, want DataDirError", dataDir, err)
+	}
+	if dde.Path != dataDir || got != dataDir {
+		t.Errorf("DataDirError.Path = %q, result %q, want %q", dde.Path, got, dataDir)
+	}
+	if !strings.HasPrefix(err.Error(), "DataDir") {
+		t.Error("missing prefix")
+	}
+}
+
+func TestDefaultDataDir_PrefersSystemdStateDirectory(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("STATE_DIRECTORY", stateDir+string(filepath.ListSeparator)+filepath.Join(stateDir, "other"))
+	if got, err := webserv.DefaultDataDir("", "suffix"); err != nil || got != stateDir {
+		t.Errorf("DefaultDataDir(\"\", \"suffix\") = (%q, %v), want %q", got, err, stateDir)
+	}
+	want, err := filepath.Abs("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := webserv.DefaultDataDir("foo", "suffix"); err != nil || got != want {
+		t.Errorf("DefaultDataDir(\"foo\", \"suffix\") = (%q, %v), want %q", got, err, want)
+	}
+	if got, err := webserv.DefaultDataDir("", ""); err != nil || got != "" {
+		t.Errorf("DefaultDataDir(\"\", \"\") = (%q, %v), want empty", got, err)
+	}
+}
+
+func TestDefaultDataDir_SystemdStateDirectoryIsNotExpanded(t *testing.T) {
+	stateDir := filepath.Join(t.TempDir(), "app$HOME")
+	t.Setenv("STATE_DIRECTORY", stateDir)
+	if got, err := webserv.DefaultDataDir("", "suffix"); err != nil || got != stateDir {
+		t.Errorf("DefaultDataDir(\"\", \"suffix\") = (%q, %v), want %q verbatim", got, err, stateDir)
+	}
+}
+
+func TestDefaultDataDirStrict_RejectsEscapingSuffix(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("STATE_DIRECTORY", "")
+	for _, suffix := range []string{"..", filepath.Join("..", "x"), filepath.Join("a", "..", "..", "x")} {
+		got, err := webserv.DefaultDataDirStrict("", suffix)
+		if got != "" || !errors.Is(err, webserv.ErrDataDir) || !errors.Is(err, webserv.ErrPathEscape) {
+			t.Errorf("DefaultDataDirStrict(%q) = (%q, %v), want match %v and %v", suffix, got, err, webserv.ErrDataDir, webserv.ErrPathEscape)
+		}
+	}
+	if got, err := webserv.DefaultDataDirStrict("", "app"); err != nil || !strings.HasSuffix(got, "app") {
+		t.Errorf("DefaultDataDirStrict(\"app\") = (%q, %v), want path ending in app", got, err)
+	}
+	// dataDir itself is trusted.
+	if _, err := webserv.DefaultDataDirStrict(filepath.Join("..", "x"), "app"); err != nil {
+		t.Errorf("DefaultDataDirStrict(\"../x\", \"app\") = %v, want nil", err)
+	}
+}
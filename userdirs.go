@@ -0,0 +1,168 @@
+package webserv
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// UserDataDir returns the default root directory to use for user-specific
+// data files, like [os.UserConfigDir] does for configuration files.
+//
+// On Unix systems, it returns $XDG_DATA_HOME if non-empty, else
+// $HOME/.local/share. On Darwin, it returns $HOME/Library/Application Support.
+// On Windows, it returns %LocalAppData%. On Plan 9, it returns $home/lib.
+//
+// If the location cannot be determined (for example, $HOME is not defined) or
+// the path in $XDG_DATA_HOME is relative, then it will return an error.
+func UserDataDir() (string, error) {
+	return userDir("XDG_DATA_HOME", ".local/share")
+}
+
+// UserStateDir returns the default root directory to use for user-specific
+// state files, such as logs and history, that should persist across restarts
+// but are not important enough for [UserDataDir].
+//
+// On Unix systems, it returns $XDG_STATE_HOME if non-empty, else
+// $HOME/.local/state. Other systems use the same locations as [UserDataDir].
+//
+// If the location cannot be determined (for example, $HOME is not defined) or
+// the path in $XDG_STATE_HOME is relative, then it will return an error.
+func UserStateDir() (string, error) {
+	return userDir("XDG_STATE_HOME", ".local/state")
+}
+
+// UserRuntimeDir returns $XDG_RUNTIME_DIR, the directory for user-specific
+// runtime files such as sockets and lock files. There is no fallback, since
+// the directory must be owned by the user and be removed when they log out.
+//
+// If $XDG_RUNTIME_DIR is not defined or is relative, then it will return an
+// error.
+func UserRuntimeDir() (dir string, err error) {
+	if dir = os.Getenv("XDG_RUNTIME_DIR"); dir == "" {
+		err = errors.New("$XDG_RUNTIME_DIR is not defined")
+	} else if !filepath.IsAbs(dir) {
+		err = errors.New("path in $XDG_RUNTIME_DIR is relative")
+	}
+	return
+}
+
+func userDir(env, homeRel string) (dir string, err error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir = os.Getenv("LocalAppData"); dir == "" {
+			err = errors.New("%LocalAppData% is not defined")
+		}
+	case "darwin", "ios":
+		if dir = os.Getenv("HOME"); dir == "" {
+			err = errors.New("$HOME is not defined")
+		} else {
+			dir = filepath.Join(dir, "Library", "Application Support")
+		}
+	case "plan9":
+		if dir = os.Getenv("home"); dir == "" {
+			err = errors.New("$home is not defined")
+		} else {
+			dir = filepath.Join(dir, "lib")
+		}
+	default:
+		if dir = os.Getenv(env); dir == "" {
+			if dir = os.Getenv("HOME"); dir == "" {
+				err = errors.New("neither $" + env + " nor $HOME are defined")
+			} else {
+				dir = filepath.Join(dir, filepath.FromSlash(homeRel))
+			}
+		} else if !filepath.IsAbs(dir) {
+			err = errors.New("path in $" + env + " is relative")
+		}
+	}
+	if err != nil {
+		dir = ""
+	}
+	return
+}
+
+// systemdDirectory returns the first path in the systemd directory variable
+// env, such as STATE_DIRECTORY, which holds a colon-separated list when the
+// unit names several directories.
+func systemdDirectory(env string) (dir string) {
+	dir, _, _ = strings.Cut(os.Getenv(env), ":")
+	return
+}
+
+// userDirSpec describes one of the directories besides DataDir that Listen
+// resolves and optionally creates.
+type userDirSpec struct {
+	name     string                 // for logging, such as "state directory"
+	dir      *string                // Config field holding the directory
+	mode     fs.FileMode            // Config mode to create it with, zero to not create it
+	systemd  string                 // systemd variable that overrides the default
+	base     func() (string, error) // default base directory to append DefaultDataDirSuffix to
+	optional bool                   // if set, an unresolvable base leaves the directory empty
+	readOnly bool                   // if set, Landlock grants only read access
+}
+
+func (cfg *Config) userDirs() []userDirSpec {
+	return []userDirSpec{
+		{name: "config directory", dir: &cfg.ConfigDir, mode: cfg.ConfigDirMode, systemd: "CONFIGURATION_DIRECTORY", base: os.UserConfigDir, readOnly: true},
+		{name: "state directory", dir: &cfg.StateDir, mode: cfg.StateDirMode, systemd: "STATE_DIRECTORY", base: UserStateDir},
+		{name: "cache directory", dir: &cfg.CacheDir, mode: cfg.CacheDirMode, systemd: "CACHE_DIRECTORY", base: os.UserCacheDir},
+		{name: "runtime directory", dir: &cfg.RuntimeDir, mode: cfg.RuntimeDirMode, systemd: "RUNTIME_DIRECTORY", base: UserRuntimeDir, optional: true},
+	}
+}
+
+// resolveDirs sets cfg.DataDir and the other directories to absolute paths,
+// filling in defaults for those that are unset. A directory provided by
+// systemd is used as is. Otherwise, a directory with a nonzero mode defaults
+// to cfg.DefaultDataDirSuffix appended to its base directory; one with a zero
+// mode is left empty, since the application does not ask for it.
+func (cfg *Config) resolveDirs() (err error) {
+	if cfg.DataDirXDG {
+		cfg.DataDir, err = defaultDir(cfg.DataDir, systemdDataDir(cfg.DataDir, cfg.DefaultDataDirSuffix), cfg.DefaultDataDirSuffix, UserDataDir, cfg.StrictPaths)
+	} else {
+		cfg.DataDir, err = defaultDir(cfg.DataDir, systemdDataDir(cfg.DataDir, cfg.DefaultDataDirSuffix), cfg.DefaultDataDirSuffix, os.UserConfigDir, cfg.StrictPaths)
+	}
+	for _, d := range cfg.userDirs() {
+		if err == nil {
+			var systemdDir string
+			if *d.dir == "" {
+				systemdDir = systemdDirectory(d.systemd)
+			}
+			given := *d.dir != "" || systemdDir != ""
+			if !given && d.mode == 0 {
+				continue
+			}
+			if !given && d.optional && cfg.DefaultDataDirSuffix != "" {
+				if _, baseErr := d.base(); baseErr != nil {
+					continue
+				}
+			}
+			*d.dir, err = defaultDir(*d.dir, systemdDir, cfg.DefaultDataDirSuffix, d.base, cfg.StrictPaths)
+		}
+	}
+	return
+}
+
+// useDirs creates the directories besides cfg.DataDir that have a nonzero
+// mode, and makes them absolute.
+func (cfg *Config) useDirs() (err error) {
+	for _, d := range cfg.userDirs() {
+		if err == nil && *d.dir != "" {
+			if *d.dir, err = UseDataDir(*d.dir, d.mode); err == nil {
+				cfg.logInfo(d.name, "dir", *d.dir)
+			}
+		}
+	}
+	return
+}
+
+// clearDirs resets cfg.DataDir and the other directories to empty.
+func (cfg *Config) clearDirs() {
+	cfg.DataDir = ""
+	for _, d := range cfg.userDirs() {
+		*d.dir = ""
+	}
+}
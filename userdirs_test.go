@@ -0,0 +1,144 @@
+package webserv_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/linkdata/webserv"
+)
+
+func unsetenv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		if err := os.Unsetenv(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func skipUnlessXDG(t *testing.T) {
+	t.Helper()
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		t.Skipf("XDG base directories are not used on %s", runtime.GOOS)
+	}
+}
+
+func TestUserDataDirAndStateDir_HonorXDG(t *testing.T) {
+	skipUnlessXDG(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	unsetenv(t, "XDG_DATA_HOME", "XDG_STATE_HOME")
+	if got, err := webserv.UserDataDir(); err != nil || got != filepath.Join(home, ".local", "share") {
+		t.Errorf("UserDataDir() = (%q, %v), want $HOME/.local/share", got, err)
+	}
+	if got, err := webserv.UserStateDir(); err != nil || got != filepath.Join(home, ".local", "state") {
+		t.Errorf("UserStateDir() = (%q, %v), want $HOME/.local/state", got, err)
+	}
+	t.Setenv("XDG_DATA_HOME", "/xdg/data")
+	t.Setenv("XDG_STATE_HOME", "relative")
+	if got, err := webserv.UserDataDir(); err != nil || got != "/xdg/data" {
+		t.Errorf("UserDataDir() = (%q, %v), want %q", got, err, "/xdg/data")
+	}
+	if got, err := webserv.UserStateDir(); err == nil {
+		t.Errorf("UserStateDir() = %q with relative XDG_STATE_HOME, want error", got)
+	}
+}
+
+func TestUserRuntimeDir(t *testing.T) {
+	unsetenv(t, "XDG_RUNTIME_DIR")
+	if got, err := webserv.UserRuntimeDir(); err == nil {
+		t.Errorf("UserRuntimeDir() = %q without XDG_RUNTIME_DIR, want error", got)
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	if got, err := webserv.UserRuntimeDir(); err != nil || got != dir {
+		t.Errorf("UserRuntimeDir() = (%q, %v), want %q", got, err, dir)
+	}
+}
+
+func TestConfigListen_ResolvesAndCreatesUserDirs(t *testing.T) {
+	skipUnlessXDG(t)
+	base := t.TempDir()
+	unsetenv(t, "CONFIGURATION_DIRECTORY", "STATE_DIRECTORY", "CACHE_DIRECTORY", "RUNTIME_DIRECTORY", "XDG_RUNTIME_DIR")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(base, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(base, "cache"))
+	systemdState := filepath.Join(base, "systemd-state")
+	t.Setenv("STATE_DIRECTORY", systemdState+":"+filepath.Join(base, "other"))
+
+	cfg := &webserv.Config{
+		Address:              "127.0.0.1:0",
+		DefaultDataDirSuffix: "app",
+		DataDirXDG:           true,
+		DataDirMode:          0o750,
+		StateDirMode:         0o750,
+		CacheDirMode:         0o750,
+		RuntimeDirMode:       0o700,
+	}
+	l, err := cfg.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Close()
+
+	for _, tc := range []struct{ name, got, want string }{
+		{"DataDir", cfg.DataDir, systemdState},
+		{"ConfigDir", cfg.ConfigDir, ""},
+		{"StateDir", cfg.StateDir, systemdState},
+		{"CacheDir", cfg.CacheDir, filepath.Join(base, "cache", "app")},
+		{"RuntimeDir", cfg.RuntimeDir, ""},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s = %q, want %q", tc.name, tc.got, tc.want)
+		}
+	}
+	for _, dir := range []string{cfg.DataDir, cfg.StateDir, cfg.CacheDir} {
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			t.Errorf("%q not created: %v", dir, err)
+		}
+	}
+	// ConfigDirMode is zero, so the config directory is neither resolved
+	// nor created.
+	if _, err = os.Stat(filepath.Join(base, "config", "app")); !os.IsNotExist(err) {
+		t.Errorf("config directory created without ConfigDirMode: %v", err)
+	}
+}
+
+func TestConfigListen_DataDirXDG(t *testing.T) {
+	skipUnlessXDG(t)
+	base := t.TempDir()
+	unsetenv(t, "STATE_DIRECTORY")
+	t.Setenv("XDG_DATA_HOME", base)
+
+	cfg := &webserv.Config{Address: "127.0.0.1:0", DefaultDataDirSuffix: "app", DataDirXDG: true}
+	l, err := cfg.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Close()
+	if want := filepath.Join(base, "app"); cfg.DataDir != want {
+		t.Errorf("DataDir = %q, want %q", cfg.DataDir, want)
+	}
+}
+
+func TestConfigListen_UnusedUserDirsAreNotResolved(t *testing.T) {
+	skipUnlessXDG(t)
+	unsetenv(t, "CONFIGURATION_DIRECTORY", "STATE_DIRECTORY", "CACHE_DIRECTORY", "RUNTIME_DIRECTORY", "XDG_RUNTIME_DIR")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	// A relative XDG_STATE_HOME would fail to resolve the state directory.
+	t.Setenv("XDG_STATE_HOME", "relative")
+
+	cfg := &webserv.Config{Address: "127.0.0.1:0", DefaultDataDirSuffix: "app"}
+	l, err := cfg.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Close()
+	if cfg.ConfigDir != "" || cfg.StateDir != "" || cfg.CacheDir != "" || cfg.RuntimeDir != "" {
+		t.Fatalf("directories without a mode resolved: %q, %q, %q, %q", cfg.ConfigDir, cfg.StateDir, cfg.CacheDir, cfg.RuntimeDir)
+	}
+}
@@ -0,0 +1,175 @@
+package webserv
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The types below implement [flag.Value] for the kinds of Config fields that
+// can be set from text. They tolerate nil pointers, since the flag package
+// calls String on zero values.
+
+type stringValue struct{ p *string }
+
+func (v stringValue) Set(s string) error {
+	*v.p = s
+	return nil
+}
+
+func (v stringValue) String() (s string) {
+	if v.p != nil {
+		s = *v.p
+	}
+	return
+}
+
+type boolValue struct{ p *bool }
+
+func (v boolValue) Set(s string) (err error) {
+	var b bool
+	if b, err = strconv.ParseBool(s); err == nil {
+		*v.p = b
+	}
+	return
+}
+
+func (v boolValue) String() string {
+	return strconv.FormatBool(v.p != nil && *v.p)
+}
+
+func (v boolValue) IsBoolFlag() bool {
+	return true
+}
+
+// listValue is a comma-separated list.
+type listValue struct{ p *[]string }
+
+func (v listValue) Set(s string) error {
+	var list []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*v.p = list
+	return nil
+}
+
+func (v listValue) String() (s string) {
+	if v.p != nil {
+		s = strings.Join(*v.p, ",")
+	}
+	return
+}
+
+// modeValue is a permission mode in octal, such as "0750".
+type modeValue struct{ p *fs.FileMode }
+
+func (v modeValue) Set(s string) (err error) {
+	var n uint64
+	if n, err = strconv.ParseUint(s, 8, 32); err == nil {
+		if n > uint64(fs.ModePerm) {
+			err = fmt.Errorf("mode %q is not between 0 and 0777", s)
+		} else {
+			*v.p = fs.FileMode(n)
+		}
+	}
+	return
+}
+
+func (v modeValue) String() (s string) {
+	if v.p != nil && *v.p != 0 {
+		s = fmt.Sprintf("%#o", uint32(*v.p))
+	}
+	return
+}
+
+type uint64Value struct{ p *uint64 }
+
+func (v uint64Value) Set(s string) (err error) {
+	var n uint64
+	if n, err = strconv.ParseUint(s, 10, 64); err == nil {
+		*v.p = n
+	}
+	return
+}
+
+func (v uint64Value) String() (s string) {
+	if v.p != nil && *v.p != 0 {
+		s = strconv.FormatUint(*v.p, 10)
+	}
+	return
+}
+
+type durationValue struct{ p *time.Duration }
+
+func (v durationValue) Set(s string) (err error) {
+	var d time.Duration
+	if d, err = time.ParseDuration(s); err == nil {
+		*v.p = d
+	}
+	return
+}
+
+func (v durationValue) String() (s string) {
+	if v.p != nil && *v.p != 0 {
+		s = v.p.String()
+	}
+	return
+}
+
+// signalValue is a signal name such as "SIGUSR1".
+type signalValue struct{ p *os.Signal }
+
+func (v signalValue) Set(s string) (err error) {
+	var sig os.Signal
+	if sig, err = parseSignal(s); err == nil {
+		*v.p = sig
+	}
+	return
+}
+
+func (v signalValue) String() (s string) {
+	if v.p != nil && *v.p != nil {
+		s = signalName(*v.p)
+	}
+	return
+}
+
+// signalsValue is a comma-separated list of signal=action pairs, such as
+// "SIGQUIT=shutdown,SIGINT=ignore".
+type signalsValue struct{ p *map[os.Signal]SignalAction }
+
+func (v signalsValue) Set(s string) (err error) {
+	signals := make(map[os.Signal]SignalAction)
+	for pair := range strings.SplitSeq(s, ",") {
+		if pair = strings.TrimSpace(pair); pair != "" && err == nil {
+			name, actionName, _ := strings.Cut(pair, "=")
+			var sig os.Signal
+			if sig, err = parseSignal(name); err == nil {
+				signals[sig], err = parseSignalAction(actionName)
+			}
+		}
+	}
+	if err == nil {
+		*v.p = signals
+	}
+	return
+}
+
+func (v signalsValue) String() (s string) {
+	if v.p != nil {
+		var pairs []string
+		for sig, action := range *v.p {
+			pairs = append(pairs, signalName(sig)+"="+action.String())
+		}
+		slices.Sort(pairs)
+		s = strings.Join(pairs, ",")
+	}
+	return
+}